@@ -0,0 +1,64 @@
+// Command sign-webhook stands in for a payment gateway during development. It
+// signs the event read from stdin the way gateways sign their webhooks and
+// sends it to the server, e.g.
+//
+//	echo '{"id":"evt_1","type":"payment.captured","transaction_id":"fake_booking-1_1","amount":120}' |
+//		go run ./cmd/sign-webhook -secret "$SECRET" -url http://localhost:8080/webhooks/payments/fake
+//
+// Without -url it prints the headers to send instead.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"backend/src/payments"
+)
+
+func main() {
+	secret := flag.String("secret", "", "webhook secret of the provider")
+	url := flag.String("url", "", "webhook endpoint to send the event to")
+	flag.Parse()
+
+	if *secret == "" {
+		log.Fatalf("-secret must be set")
+	}
+
+	body, err := io.ReadAll(os.Stdin)
+	if err != nil {
+		log.Fatalf("error reading event: %v", err)
+	}
+	body = bytes.TrimSpace(body)
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	signature := payments.Sign(*secret, timestamp, body)
+
+	if *url == "" {
+		fmt.Printf("%s: %s\n%s: %s\n", payments.TimestampHeader, timestamp, payments.SignatureHeader, signature)
+		return
+	}
+
+	request, err := http.NewRequest(http.MethodPost, *url, bytes.NewReader(body))
+	if err != nil {
+		log.Fatalf("error building request: %v", err)
+	}
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set(payments.TimestampHeader, timestamp)
+	request.Header.Set(payments.SignatureHeader, signature)
+
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		log.Fatalf("error sending event: %v", err)
+	}
+	defer response.Body.Close()
+
+	reply, _ := io.ReadAll(response.Body)
+	fmt.Printf("%s %s\n", response.Status, reply)
+}
@@ -1,10 +1,16 @@
 package src
 
 import (
+	"context"
 	"log"
-	"os"
+	"time"
 
+	"backend/src/auth"
 	handlers2 "backend/src/handlers"
+	"backend/src/notifications"
+	"backend/src/payments"
+	"backend/src/pricing"
+	"backend/src/scheduler"
 	"backend/src/services"
 	"backend/src/sql"
 
@@ -19,6 +25,8 @@ func Run() error {
 		log.Fatalf("Error loading .env file: %v", err)
 	}
 
+	config := LoadConfig()
+
 	e := echo.New()
 
 	e.Use(middleware.Logger())
@@ -28,31 +36,107 @@ func Run() error {
 	e.Use(middleware.CORSWithConfig(middleware.CORSConfig{
 		AllowOrigins: []string{"*"},
 		AllowMethods: []string{echo.GET, echo.POST, echo.PUT, echo.DELETE, echo.PATCH},
-		AllowHeaders: []string{echo.HeaderOrigin, echo.HeaderContentType, echo.HeaderAccept},
+		AllowHeaders: []string{echo.HeaderOrigin, echo.HeaderContentType, echo.HeaderAccept, echo.HeaderAuthorization},
 	}))
 
 	// Custom validator
 	e.Validator = NewCustomValidator()
 
 	// Setup database
-	database := sql.NewClient(os.Getenv("DATABASE_DSN"))
+	database := sql.NewClient(config.DatabaseDSN)
+
+	// Authentication
+	tokens := auth.NewTokens(config.JWTSecret, config.AccessTokenTTL, config.RefreshTokenTTL)
+	passwords := auth.NewPasswords(config.BcryptCost)
+	authenticate := auth.Middleware(tokens, database)
 
 	// Users handler
-	usersService := services.NewUsersService(database)
-	usersHandler := handlers2.NewUsersHandler(usersService)
+	usersService := services.NewUsersService(database, tokens, passwords)
+	usersHandler := handlers2.NewUsersHandler(usersService, authenticate)
 
 	// Bookings handler
-	bookingsService := services.NewBookingsService(database)
-	bookingsHandler := handlers2.NewBookingsHandler(bookingsService)
+	calculator := pricing.NewCalculator(pricing.Rules{
+		Increment: config.PricingIncrement,
+		Grace:     config.PricingGrace,
+		TaxRate:   config.TaxRate,
+	})
+	quotes := pricing.NewQuotes(config.JWTSecret, config.QuoteTTL)
+
+	var paymentProvider payments.PaymentProvider
+	switch config.PaymentsProvider {
+	case "fake":
+		paymentProvider = payments.NewFakeProvider(config.FakePaymentsDeclineAbove)
+	default:
+		log.Fatalf("unknown payments provider %q", config.PaymentsProvider)
+	}
+
+	bookingsService := services.NewBookingsService(database, notifications.NewLogNotifier(), calculator, quotes, paymentProvider, services.BookingsConfig{
+		ReservationHold:  config.ReservationHold,
+		NoShowGrace:      config.NoShowGrace,
+		OverdueGrace:     config.OverdueGrace,
+		MaxAutoExtension: config.MaxAutoExtension,
+		Turnaround: services.Turnaround{
+			Default:       config.TurnaroundBuffer,
+			ByVehicleType: config.TurnaroundBufferByType,
+		},
+		Deposits: services.Deposits{
+			Default:       config.Deposit,
+			ByVehicleType: config.DepositByType,
+		},
+		Cancellation: services.CancellationPolicy{
+			FreePeriod: config.FreeCancellationPeriod,
+			LateFee:    config.LateCancellationFee,
+			NoShowFee:  config.NoShowFee,
+		},
+	})
+	bookingsHandler := handlers2.NewBookingsHandler(bookingsService, authenticate)
+
+	// Vehicles handler
+	vehiclesService := services.NewVehiclesService(database)
+	vehiclesHandler := handlers2.NewVehiclesHandler(vehiclesService, authenticate)
+
+	// Promotions handler
+	promotionsService := services.NewPromotionsService(database)
+	promotionsHandler := handlers2.NewPromotionsHandler(promotionsService, authenticate)
+
+	// Webhooks handler
+	webhooks := payments.NewWebhooks(config.PaymentsWebhookSecrets, config.PaymentsWebhookTolerance)
+	webhooksHandler := handlers2.NewWebhooksHandler(bookingsService, webhooks)
 
 	handlers := []handlers2.Handler{
 		usersHandler,
 		bookingsHandler,
+		vehiclesHandler,
+		promotionsHandler,
+		webhooksHandler,
 	}
 
 	for _, handler := range handlers {
 		handler.AddRoutes(e.Router())
 	}
 
-	return e.Start(os.Getenv("SERVER_PORT"))
+	// Background jobs
+	jobs := scheduler.New(
+		scheduler.Job{
+			Name:     "expire-reservations",
+			Interval: config.ExpiryInterval,
+			Run: func(now time.Time) error {
+				_, err := bookingsService.ExpireReservations(now)
+				return err
+			},
+		},
+		scheduler.Job{
+			Name:     "flag-no-shows-and-overdue",
+			Interval: config.OverdueCheckInterval,
+			Run:      bookingsService.FlagNoShowsAndOverdue,
+		},
+		scheduler.Job{
+			Name:     "sync-refunds",
+			Interval: config.RefundCheckInterval,
+			Run:      bookingsService.SyncRefunds,
+		},
+	)
+	jobs.Start(context.Background())
+
+	return e.Start(config.ServerPort)
 }
@@ -0,0 +1,97 @@
+package auth
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"backend/src/domain"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	principalKey = "principal"
+	sessionKey   = "session"
+)
+
+type UsersDatabase interface {
+	GetUserById(id uint) (*domain.User, error)
+	GetSessionById(id uint) (*domain.Session, error)
+}
+
+// Middleware resolves the bearer token of the request into the authenticated
+// user and stores it in the request context. Requests without a valid token
+// are rejected before reaching the handler.
+func Middleware(tokens *Tokens, database UsersDatabase) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			header := c.Request().Header.Get(echo.HeaderAuthorization)
+			token, found := strings.CutPrefix(header, "Bearer ")
+			if !found || token == "" {
+				return c.JSON(http.StatusUnauthorized, echo.Map{
+					"message": "missing access token",
+				})
+			}
+
+			claims, err := tokens.ParseAccessToken(token)
+			if err != nil {
+				return c.JSON(http.StatusUnauthorized, echo.Map{
+					"message": err.Error(),
+				})
+			}
+
+			session, err := database.GetSessionById(claims.SessionID)
+			if err != nil {
+				return c.JSON(http.StatusInternalServerError, echo.Map{
+					"message": err.Error(),
+				})
+			}
+
+			if session == nil || session.UserID != claims.UserID() || session.RevokedAt != nil || session.ExpiresAt.Before(time.Now()) {
+				return c.JSON(http.StatusUnauthorized, echo.Map{
+					"message": "session revoked or expired",
+				})
+			}
+
+			user, err := database.GetUserById(claims.UserID())
+			if err != nil {
+				return c.JSON(http.StatusInternalServerError, echo.Map{
+					"message": err.Error(),
+				})
+			}
+
+			if user == nil {
+				return c.JSON(http.StatusUnauthorized, echo.Map{
+					"message": "invalid access token",
+				})
+			}
+
+			c.Set(principalKey, user)
+			c.Set(sessionKey, session)
+
+			return next(c)
+		}
+	}
+}
+
+// Principal returns the user resolved by Middleware, or nil when the route is
+// not authenticated.
+func Principal(c echo.Context) *domain.User {
+	user, ok := c.Get(principalKey).(*domain.User)
+	if !ok {
+		return nil
+	}
+
+	return user
+}
+
+// Session returns the session the access token of the request belongs to.
+func Session(c echo.Context) *domain.Session {
+	session, ok := c.Get(sessionKey).(*domain.Session)
+	if !ok {
+		return nil
+	}
+
+	return session
+}
@@ -0,0 +1,60 @@
+package auth
+
+import (
+	"crypto/subtle"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// MaxPasswordBytes is the longest password bcrypt can hash.
+const MaxPasswordBytes = 72
+
+type Passwords struct {
+	cost      int
+	dummyHash []byte
+}
+
+func NewPasswords(cost int) *Passwords {
+	if cost < bcrypt.MinCost || cost > bcrypt.MaxCost {
+		cost = bcrypt.DefaultCost
+	}
+
+	// Used to spend the same time verifying unknown accounts as known ones
+	dummyHash, _ := bcrypt.GenerateFromPassword([]byte("dummy-password"), cost)
+
+	return &Passwords{
+		cost:      cost,
+		dummyHash: dummyHash,
+	}
+}
+
+func (p *Passwords) Hash(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), p.cost)
+	if err != nil {
+		return "", err
+	}
+
+	return string(hash), nil
+}
+
+// Verify reports whether password matches the stored hash and whether the
+// stored value must be replaced with a fresh hash. Stored values that are not
+// bcrypt hashes are legacy plaintext passwords and always need a rehash.
+func (p *Passwords) Verify(stored string, password string) (valid bool, needsRehash bool) {
+	cost, err := bcrypt.Cost([]byte(stored))
+	if err != nil {
+		valid = subtle.ConstantTimeCompare([]byte(stored), []byte(password)) == 1
+		return valid, valid
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(stored), []byte(password)); err != nil {
+		return false, false
+	}
+
+	return true, cost != p.cost
+}
+
+// VerifyMissing burns the same time as Verify for users that do not exist.
+func (p *Passwords) VerifyMissing(password string) {
+	_ = bcrypt.CompareHashAndPassword(p.dummyHash, []byte(password))
+}
@@ -0,0 +1,116 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"strconv"
+	"time"
+
+	"backend/src/commons"
+	"backend/src/domain"
+
+	"github.com/golang-jwt/jwt"
+)
+
+type Claims struct {
+	Type      string `json:"type"`
+	SessionID uint   `json:"sid"`
+	jwt.StandardClaims
+}
+
+// UserID returns the id of the user the token was issued to.
+func (c Claims) UserID() uint {
+	id, err := strconv.ParseUint(c.Subject, 10, 32)
+	if err != nil {
+		return 0
+	}
+
+	return uint(id)
+}
+
+type TokenPair struct {
+	AccessToken           string
+	AccessTokenExpiresAt  time.Time
+	RefreshToken          string
+	RefreshTokenExpiresAt time.Time
+}
+
+type Tokens struct {
+	secret          []byte
+	accessTokenTTL  time.Duration
+	refreshTokenTTL time.Duration
+}
+
+func NewTokens(secret string, accessTokenTTL time.Duration, refreshTokenTTL time.Duration) *Tokens {
+	return &Tokens{
+		secret:          []byte(secret),
+		accessTokenTTL:  accessTokenTTL,
+		refreshTokenTTL: refreshTokenTTL,
+	}
+}
+
+// RefreshTokenTTL is the lifetime of a session without being refreshed.
+func (t *Tokens) RefreshTokenTTL() time.Duration {
+	return t.refreshTokenTTL
+}
+
+func (t *Tokens) IssueAccessToken(user domain.User, sessionID uint) (string, time.Time, error) {
+	now := time.Now()
+	expiresAt := now.Add(t.accessTokenTTL)
+
+	claims := Claims{
+		Type:      user.Type,
+		SessionID: sessionID,
+		StandardClaims: jwt.StandardClaims{
+			Subject:   strconv.FormatUint(uint64(user.ID), 10),
+			IssuedAt:  now.Unix(),
+			ExpiresAt: expiresAt.Unix(),
+		},
+	}
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(t.secret)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	return token, expiresAt, nil
+}
+
+func (t *Tokens) ParseAccessToken(token string) (*Claims, error) {
+	claims := &Claims{}
+	parsed, err := jwt.ParseWithClaims(token, claims, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, commons.ErrInvalidToken
+		}
+		return t.secret, nil
+	})
+	if err != nil || !parsed.Valid {
+		return nil, commons.ErrInvalidToken
+	}
+
+	if claims.UserID() == 0 || claims.SessionID == 0 {
+		return nil, commons.ErrInvalidToken
+	}
+
+	return claims, nil
+}
+
+// NewRefreshToken returns an opaque refresh token and the hash that must be
+// stored for it. The token itself is never persisted.
+func NewRefreshToken() (string, string, error) {
+	buffer := make([]byte, 32)
+	if _, err := rand.Read(buffer); err != nil {
+		return "", "", err
+	}
+
+	token := base64.RawURLEncoding.EncodeToString(buffer)
+
+	return token, HashRefreshToken(token), nil
+}
+
+func HashRefreshToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
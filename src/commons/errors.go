@@ -8,13 +8,50 @@ var (
 	ErrUserAlreadyExists          = errors.New("user already exists")
 	ErrUserNotFound               = errors.New("user not found")
 	ErrInvalidCredentials         = errors.New("invalid credentials")
+	ErrForbidden                  = errors.New("forbidden")
 	ErrVehicleNotAvailable        = errors.New("vehicle not available")
 	ErrVehicleNotFound            = errors.New("vehicle not found")
+	ErrVehicleHasActiveBookings   = errors.New("vehicle has active bookings")
+	ErrInvalidVehicleStatus       = errors.New("invalid vehicle status transition")
+	ErrMaintenanceWindowNotFound  = errors.New("maintenance window not found")
 	ErrBookingNotFound            = errors.New("booking not found")
 	ErrBookingAlreadyCancelled    = errors.New("booking already cancelled")
 	ErrBookingAlreadyFinished     = errors.New("booking already finished")
 	ErrBookingAlreadyStarted      = errors.New("booking already started")
+	ErrBookingAlreadyConfirmed    = errors.New("booking already confirmed")
+	ErrBookingNotConfirmed        = errors.New("booking not confirmed")
 	ErrBookingNotStarted          = errors.New("booking not started")
 	ErrBookingNotFinished         = errors.New("booking not finished")
 	ErrBookingAlreadyHaveFeedback = errors.New("booking already have feedback")
+	ErrBookingDatesPassed         = errors.New("booking dates already passed")
+	ErrBookingExpired             = errors.New("booking expired")
+	ErrBookingNoShow              = errors.New("booking was a no show")
+	ErrBookingNotReschedulable    = errors.New("only reserved or confirmed bookings can be rescheduled")
+	ErrBookingTooShort            = errors.New("booking is shorter than the minimum rental of the vehicle")
+	ErrInvalidQuote               = errors.New("invalid or expired quote")
+	ErrQuoteMismatch              = errors.New("quote does not match the booking")
+	ErrBookingNotExtendable       = errors.New("only bookings in progress can be extended")
+	ErrInvalidExtension           = errors.New("extension must end after the current end date")
+	ErrExtensionAlreadyPending    = errors.New("booking already has a pending extension")
+	ErrExtensionNotFound          = errors.New("extension not found")
+	ErrExtensionNotPending        = errors.New("extension was already reviewed")
+	ErrPromotionNotFound          = errors.New("promotion not found")
+	ErrPromotionAlreadyExists     = errors.New("promotion code already exists")
+	ErrInvalidPromoCode           = errors.New("invalid or expired promo code")
+	ErrPromotionExhausted         = errors.New("promo code usage limit reached")
+	ErrPaymentDeclined            = errors.New("payment declined")
+	ErrPaymentNotFound            = errors.New("payment not found")
+	ErrInvalidPaymentOperation    = errors.New("invalid payment operation")
+	ErrBookingNotChargeable       = errors.New("charges can only be posted on bookings in progress")
+	ErrRefundNotFound             = errors.New("refund not found")
+	ErrUnknownPaymentProvider     = errors.New("unknown payment provider")
+	ErrInvalidWebhookSignature    = errors.New("invalid webhook signature")
+	ErrInvalidWebhookEvent        = errors.New("invalid webhook event")
+	ErrInvalidBookingTransition   = errors.New("invalid booking status transition")
+	ErrBookingStatusChanged       = errors.New("booking status changed, try again")
+	ErrInvalidOdometer            = errors.New("return odometer cannot be lower than pick up odometer")
+	ErrInvalidToken               = errors.New("invalid access token")
+	ErrInvalidRefreshToken        = errors.New("invalid refresh token")
+	ErrRefreshTokenReused         = errors.New("refresh token reuse detected")
+	ErrSessionNotFound            = errors.New("session not found")
 )
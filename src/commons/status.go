@@ -1,13 +1,114 @@
 package commons
 
 const (
-	VehicleStatusAvailable = "disponible"
+	VehicleStatusAvailable    = "disponible"
+	VehicleStatusMaintenance  = "mantenimiento"
+	VehicleStatusOutOfService = "fuera_de_servicio"
+	VehicleStatusRetired      = "retirado"
 
-	BookingStatusReserved  = "reservado"
-	BookingStatusConfirmed = "confirmado"
-	BookingStatusCancelled = "cancelado"
-	BookingStatusFinished  = "finalizado"
+	VehicleTransmissionManual    = "manual"
+	VehicleTransmissionAutomatic = "automatico"
 
-	UserTypeClient = "client"
-	UserTypeAdmin  = "admin"
+	VehicleTypeSedan     = "sedan"
+	VehicleTypeHatchback = "hatchback"
+	VehicleTypeSUV       = "suv"
+	VehicleTypePickup    = "camioneta"
+	VehicleTypeVan       = "van"
+
+	BookingStatusReserved   = "reservado"
+	BookingStatusConfirmed  = "confirmado"
+	BookingStatusInProgress = "en_curso"
+	BookingStatusCancelled  = "cancelado"
+	BookingStatusExpired    = "expirado"
+	BookingStatusNoShow     = "no_presentado"
+	BookingStatusOverdue    = "atrasado"
+	BookingStatusFinished   = "finalizado"
+
+	ExtensionStatusPending  = "pendiente"
+	ExtensionStatusApproved = "aprobada"
+	ExtensionStatusRejected = "rechazada"
+
+	DiscountTypePercentage = "porcentaje"
+	DiscountTypeFixed      = "fijo"
+
+	PaymentKindRental  = "alquiler"
+	PaymentKindDeposit = "deposito"
+	PaymentKindCharges = "cargos"
+
+	ChargeKindDamage = "danos"
+	ChargeKindFuel   = "combustible"
+	ChargeKindOther  = "otros"
+
+	PaymentStatusPending    = "pendiente"
+	PaymentStatusAuthorized = "autorizado"
+	PaymentStatusCaptured   = "capturado"
+	PaymentStatusVoided     = "anulado"
+	PaymentStatusFailed     = "fallido"
+
+	RefundStatusPending   = "pendiente"
+	RefundStatusCompleted = "completado"
+	RefundStatusFailed    = "fallido"
+
+	UserTypeClient        = "client"
+	UserTypeAdmin         = "admin"
+	UserTypeFleetOperator = "fleet_operator"
+	UserTypeSupportAgent  = "support_agent"
 )
+
+// BookingStatusesBlockingVehicle are the statuses in which a booking keeps its
+// vehicle unavailable for other bookings over the same dates.
+var BookingStatusesBlockingVehicle = []string{
+	BookingStatusReserved,
+	BookingStatusConfirmed,
+	BookingStatusInProgress,
+	BookingStatusOverdue,
+}
+
+// BookingStatusesRentedOut are the statuses in which the customer has the
+// vehicle. It stays unavailable until it is returned, even past the end date.
+var BookingStatusesRentedOut = []string{
+	BookingStatusInProgress,
+	BookingStatusOverdue,
+}
+
+var BookingStatuses = []string{
+	BookingStatusReserved,
+	BookingStatusConfirmed,
+	BookingStatusInProgress,
+	BookingStatusCancelled,
+	BookingStatusExpired,
+	BookingStatusNoShow,
+	BookingStatusOverdue,
+	BookingStatusFinished,
+}
+
+var VehicleStatuses = []string{
+	VehicleStatusAvailable,
+	VehicleStatusMaintenance,
+	VehicleStatusOutOfService,
+	VehicleStatusRetired,
+}
+
+var VehicleTransmissionTypes = []string{
+	VehicleTransmissionManual,
+	VehicleTransmissionAutomatic,
+}
+
+var VehicleTypes = []string{
+	VehicleTypeSedan,
+	VehicleTypeHatchback,
+	VehicleTypeSUV,
+	VehicleTypePickup,
+	VehicleTypeVan,
+}
+
+var DiscountTypes = []string{
+	DiscountTypePercentage,
+	DiscountTypeFixed,
+}
+
+var ChargeKinds = []string{
+	ChargeKindDamage,
+	ChargeKindFuel,
+	ChargeKindOther,
+}
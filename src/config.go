@@ -0,0 +1,247 @@
+package src
+
+import (
+	"log"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"backend/src/commons"
+)
+
+type Config struct {
+	DatabaseDSN          string
+	ServerPort           string
+	JWTSecret            string
+	AccessTokenTTL       time.Duration
+	RefreshTokenTTL      time.Duration
+	BcryptCost           int
+	ReservationHold      time.Duration
+	ExpiryInterval       time.Duration
+	NoShowGrace          time.Duration
+	OverdueGrace         time.Duration
+	OverdueCheckInterval time.Duration
+	MaxAutoExtension     time.Duration
+	TurnaroundBuffer     time.Duration
+	// TurnaroundBufferByType overrides TurnaroundBuffer for some vehicle
+	// types, e.g. TURNAROUND_BUFFER_BY_TYPE=van=90m,camioneta=90m
+	TurnaroundBufferByType map[string]time.Duration
+	PricingIncrement       time.Duration
+	PricingGrace           time.Duration
+	TaxRate                float64
+	QuoteTTL               time.Duration
+	Deposit                float64
+	// DepositByType overrides Deposit for some vehicle types, e.g.
+	// DEPOSIT_BY_TYPE=van=800,suv=600
+	DepositByType map[string]float64
+	// FreeCancellationPeriod is how long before the start date bookings
+	// can still be cancelled for free. LateCancellationFee and NoShowFee
+	// are the share of the booking total charged after that, e.g. 0.5.
+	FreeCancellationPeriod time.Duration
+	LateCancellationFee    float64
+	NoShowFee              float64
+	PaymentsProvider       string
+	RefundCheckInterval    time.Duration
+	// PaymentsWebhookSecrets are the secrets payment gateways sign their
+	// webhooks with, by provider, e.g. PAYMENTS_WEBHOOK_SECRETS=fake=secret
+	PaymentsWebhookSecrets map[string]string
+	// PaymentsWebhookTolerance is how old a webhook signature can be.
+	PaymentsWebhookTolerance time.Duration
+	// FakePaymentsDeclineAbove makes the fake payments provider decline
+	// authorizations over this amount, to try out declined payments.
+	FakePaymentsDeclineAbove float64
+}
+
+func LoadConfig() Config {
+	config := Config{
+		DatabaseDSN:              os.Getenv("DATABASE_DSN"),
+		ServerPort:               os.Getenv("SERVER_PORT"),
+		JWTSecret:                os.Getenv("JWT_SECRET"),
+		AccessTokenTTL:           getEnvDuration("ACCESS_TOKEN_TTL", 15*time.Minute),
+		RefreshTokenTTL:          getEnvDuration("REFRESH_TOKEN_TTL", 30*24*time.Hour),
+		BcryptCost:               getEnvInt("BCRYPT_COST", 12),
+		ReservationHold:          getEnvDuration("RESERVATION_HOLD", 2*time.Hour),
+		ExpiryInterval:           getEnvDuration("EXPIRY_INTERVAL", time.Minute),
+		NoShowGrace:              getEnvDuration("NO_SHOW_GRACE", 2*time.Hour),
+		OverdueGrace:             getEnvDuration("OVERDUE_GRACE", 30*time.Minute),
+		OverdueCheckInterval:     getEnvDuration("OVERDUE_CHECK_INTERVAL", 5*time.Minute),
+		MaxAutoExtension:         getEnvDuration("MAX_AUTO_EXTENSION", 4*time.Hour),
+		TurnaroundBuffer:         getEnvDuration("TURNAROUND_BUFFER", time.Hour),
+		TurnaroundBufferByType:   getEnvDurations("TURNAROUND_BUFFER_BY_TYPE"),
+		PricingIncrement:         getEnvDuration("PRICING_INCREMENT", time.Hour),
+		PricingGrace:             getEnvDuration("PRICING_GRACE", 10*time.Minute),
+		TaxRate:                  getEnvFloat("TAX_RATE", 0),
+		QuoteTTL:                 getEnvDuration("QUOTE_TTL", 15*time.Minute),
+		Deposit:                  getEnvFloat("DEPOSIT", 0),
+		DepositByType:            getEnvFloats("DEPOSIT_BY_TYPE"),
+		FreeCancellationPeriod:   getEnvDuration("FREE_CANCELLATION_PERIOD", 24*time.Hour),
+		LateCancellationFee:      getEnvFloat("LATE_CANCELLATION_FEE", 0.5),
+		NoShowFee:                getEnvFloat("NO_SHOW_FEE", 1),
+		PaymentsProvider:         getEnv("PAYMENTS_PROVIDER", "fake"),
+		RefundCheckInterval:      getEnvDuration("REFUND_CHECK_INTERVAL", 5*time.Minute),
+		PaymentsWebhookSecrets:   getEnvStrings("PAYMENTS_WEBHOOK_SECRETS"),
+		PaymentsWebhookTolerance: getEnvDuration("PAYMENTS_WEBHOOK_TOLERANCE", 5*time.Minute),
+		FakePaymentsDeclineAbove: getEnvFloat("FAKE_PAYMENTS_DECLINE_ABOVE", 0),
+	}
+
+	if config.JWTSecret == "" {
+		log.Fatalf("JWT_SECRET must be set")
+	}
+
+	positive := map[string]time.Duration{
+		"RESERVATION_HOLD":       config.ReservationHold,
+		"EXPIRY_INTERVAL":        config.ExpiryInterval,
+		"OVERDUE_CHECK_INTERVAL": config.OverdueCheckInterval,
+		"REFUND_CHECK_INTERVAL":  config.RefundCheckInterval,
+	}
+	for key, duration := range positive {
+		if duration <= 0 {
+			log.Fatalf("%s must be positive", key)
+		}
+	}
+
+	for vehicleType := range config.TurnaroundBufferByType {
+		if !slices.Contains(commons.VehicleTypes, vehicleType) {
+			log.Fatalf("unknown vehicle type %q in TURNAROUND_BUFFER_BY_TYPE", vehicleType)
+		}
+	}
+
+	for vehicleType := range config.DepositByType {
+		if !slices.Contains(commons.VehicleTypes, vehicleType) {
+			log.Fatalf("unknown vehicle type %q in DEPOSIT_BY_TYPE", vehicleType)
+		}
+	}
+
+	if config.LateCancellationFee < 0 || config.LateCancellationFee > 1 {
+		log.Fatalf("LATE_CANCELLATION_FEE must be between 0 and 1")
+	}
+
+	if config.NoShowFee < 0 || config.NoShowFee > 1 {
+		log.Fatalf("NO_SHOW_FEE must be between 0 and 1")
+	}
+
+	return config
+}
+
+func getEnv(key string, fallback string) string {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+
+	return value
+}
+
+func getEnvDuration(key string, fallback time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		log.Fatalf("invalid duration for %s: %v", key, err)
+	}
+
+	return duration
+}
+
+// getEnvDurations reads a comma separated list of key=duration pairs.
+func getEnvDurations(key string) map[string]time.Duration {
+	durations := make(map[string]time.Duration)
+	value := os.Getenv(key)
+	if value == "" {
+		return durations
+	}
+
+	for _, pair := range strings.Split(value, ",") {
+		name, raw, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok {
+			log.Fatalf("invalid entry for %s: %q", key, pair)
+		}
+
+		duration, err := time.ParseDuration(raw)
+		if err != nil {
+			log.Fatalf("invalid duration for %s: %v", key, err)
+		}
+
+		durations[name] = duration
+	}
+
+	return durations
+}
+
+func getEnvInt(key string, fallback int) int {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+
+	number, err := strconv.Atoi(value)
+	if err != nil {
+		log.Fatalf("invalid integer for %s: %v", key, err)
+	}
+
+	return number
+}
+
+func getEnvFloat(key string, fallback float64) float64 {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+
+	number, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		log.Fatalf("invalid number for %s: %v", key, err)
+	}
+
+	return number
+}
+
+// getEnvStrings reads a comma separated list of key=value pairs.
+func getEnvStrings(key string) map[string]string {
+	values := make(map[string]string)
+	value := os.Getenv(key)
+	if value == "" {
+		return values
+	}
+
+	for _, pair := range strings.Split(value, ",") {
+		name, raw, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok {
+			log.Fatalf("invalid entry for %s: %q", key, pair)
+		}
+
+		values[name] = raw
+	}
+
+	return values
+}
+
+// getEnvFloats reads a comma separated list of key=number pairs.
+func getEnvFloats(key string) map[string]float64 {
+	numbers := make(map[string]float64)
+	value := os.Getenv(key)
+	if value == "" {
+		return numbers
+	}
+
+	for _, pair := range strings.Split(value, ",") {
+		name, raw, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok {
+			log.Fatalf("invalid entry for %s: %q", key, pair)
+		}
+
+		number, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			log.Fatalf("invalid number for %s: %v", key, err)
+		}
+
+		numbers[name] = number
+	}
+
+	return numbers
+}
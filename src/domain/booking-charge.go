@@ -0,0 +1,14 @@
+package domain
+
+import "gorm.io/gorm"
+
+// BookingCharge is a cost found when a vehicle is returned, such as damage or
+// missing fuel, taken from the deposit of the booking.
+type BookingCharge struct {
+	gorm.Model
+	BookingID   uint    `gorm:"not null;index"`
+	Kind        string  `gorm:"not null"`
+	Description string  `gorm:"not null"`
+	Amount      float64 `gorm:"not null"`
+	PostedByID  uint    `gorm:"not null"`
+}
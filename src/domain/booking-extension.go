@@ -0,0 +1,20 @@
+package domain
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// BookingExtension is a request to keep a rented vehicle past the end date of
+// its booking. ExtraCost is the price of the added time at the booking fare.
+type BookingExtension struct {
+	gorm.Model
+	BookingID        uint   `gorm:"not null"`
+	Status           string `gorm:"not null"`
+	RequestedByID    uint   `gorm:"not null"`
+	ReviewedByID     *uint
+	PreviousEndDate  time.Time `gorm:"not null"`
+	RequestedEndDate time.Time `gorm:"not null"`
+	ExtraCost        float64   `gorm:"not null"`
+}
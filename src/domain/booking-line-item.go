@@ -0,0 +1,14 @@
+package domain
+
+import "gorm.io/gorm"
+
+// BookingLineItem is one priced line of a booking total, e.g. three days at
+// the daily cap.
+type BookingLineItem struct {
+	gorm.Model
+	BookingID   uint    `gorm:"not null"`
+	Description string  `gorm:"not null"`
+	Quantity    float64 `gorm:"not null"`
+	UnitPrice   float64 `gorm:"not null"`
+	Amount      float64 `gorm:"not null"`
+}
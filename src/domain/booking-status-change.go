@@ -0,0 +1,14 @@
+package domain
+
+import "gorm.io/gorm"
+
+// BookingStatusChange records one transition of a booking. ActorID is nil for
+// transitions triggered by the system.
+type BookingStatusChange struct {
+	gorm.Model
+	BookingID  uint `gorm:"not null"`
+	ActorID    *uint
+	FromStatus string `gorm:"not null"`
+	ToStatus   string `gorm:"not null"`
+	Reason     string `gorm:"not null"`
+}
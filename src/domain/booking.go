@@ -20,5 +20,26 @@ type Booking struct {
 	PickUpLocation  string  `gorm:"not null"`
 	DropOffLocation string  `gorm:"not null"`
 	HourlyFare      float64 `gorm:"not null"`
+	RatePlan        `gorm:"embedded"`
+	TotalAmount     float64 `gorm:"not null;default:0"`
+	DiscountAmount  float64 `gorm:"not null;default:0"`
+	PromotionID     *uint
+	Promotion       *Promotion
+	TaxAmount       float64 `gorm:"not null;default:0"`
+	DepositAmount   float64 `gorm:"not null;default:0"`
+	CancellationFee float64 `gorm:"not null;default:0"`
+	ActualPickUpAt  *time.Time
+	PickUpOdometer  *int
+	PickUpFuelLevel *int
+	ActualReturnAt  *time.Time
+	ReturnOdometer  *int
+	ReturnFuelLevel *int
 	Messages        []BookingMessage
+	StatusChanges   []BookingStatusChange
+	Extensions      []BookingExtension
+	LineItems       []BookingLineItem
+	Payments        []Payment
+	Charges         []BookingCharge
+	Settlement      *DepositSettlement
+	Refunds         []Refund
 }
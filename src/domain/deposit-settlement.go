@@ -0,0 +1,22 @@
+package domain
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// DepositSettlement records how the deposit of a booking was settled when the
+// vehicle was returned. Of HeldAmount, CapturedAmount went to pay the charges
+// and ReleasedAmount went back to the customer. Charges the deposit did not
+// cover were charged on their own as ExtraChargedAmount.
+type DepositSettlement struct {
+	gorm.Model
+	BookingID          uint      `gorm:"not null;uniqueIndex"`
+	HeldAmount         float64   `gorm:"not null"`
+	ChargedAmount      float64   `gorm:"not null"`
+	CapturedAmount     float64   `gorm:"not null"`
+	ReleasedAmount     float64   `gorm:"not null"`
+	ExtraChargedAmount float64   `gorm:"not null"`
+	SettledAt          time.Time `gorm:"not null"`
+}
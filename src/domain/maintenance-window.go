@@ -0,0 +1,19 @@
+package domain
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// MaintenanceWindow blocks a vehicle for bookings between StartDate and
+// EndDate, the same way an active booking does.
+type MaintenanceWindow struct {
+	gorm.Model
+	VehicleID   uint `gorm:"not null"`
+	Vehicle     Vehicle
+	StartDate   time.Time `gorm:"not null"`
+	EndDate     time.Time `gorm:"not null"`
+	Reason      string    `gorm:"not null"`
+	CreatedByID uint      `gorm:"not null"`
+}
@@ -0,0 +1,16 @@
+package domain
+
+import "gorm.io/gorm"
+
+// Payment is money put on hold or taken for a booking through a payment
+// provider. Amount is what was authorized, of which CapturedAmount was taken.
+type Payment struct {
+	gorm.Model
+	BookingID         uint    `gorm:"not null;index"`
+	Provider          string  `gorm:"not null"`
+	ProviderReference string  `gorm:"index;size:191"`
+	Kind              string  `gorm:"not null"`
+	Status            string  `gorm:"not null"`
+	Amount            float64 `gorm:"not null"`
+	CapturedAmount    float64 `gorm:"not null;default:0"`
+}
@@ -0,0 +1,24 @@
+package domain
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Promotion is a discount bookings get with its code while it is valid. An
+// empty VehicleTypes applies to every vehicle, and zero usage limits mean
+// unlimited uses.
+type Promotion struct {
+	gorm.Model
+	Code           string `gorm:"not null;uniqueIndex;size:64"`
+	Description    string
+	DiscountType   string    `gorm:"not null"`
+	DiscountValue  float64   `gorm:"not null"`
+	ValidFrom      time.Time `gorm:"not null"`
+	ValidUntil     time.Time `gorm:"not null"`
+	VehicleTypes   []string  `gorm:"serializer:json"`
+	MaxUses        int       `gorm:"not null;default:0"`
+	MaxUsesPerUser int       `gorm:"not null;default:0"`
+	UsesCount      int       `gorm:"not null;default:0"`
+}
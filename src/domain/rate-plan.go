@@ -0,0 +1,11 @@
+package domain
+
+// RatePlan holds the rates a vehicle is rented at on top of its hourly fare.
+// Bookings keep a copy of the plan they were priced with. Rates left at zero
+// are not offered.
+type RatePlan struct {
+	DailyCap          float64 `gorm:"not null;default:0"`
+	WeeklyRate        float64 `gorm:"not null;default:0"`
+	WeekendHourlyFare float64 `gorm:"not null;default:0"`
+	MinimumHours      int     `gorm:"not null;default:0"`
+}
@@ -0,0 +1,21 @@
+package domain
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Refund gives back part of a captured payment. Gateways settle refunds some
+// time after they are issued, so they stay pending until the provider reports
+// them completed or failed.
+type Refund struct {
+	gorm.Model
+	BookingID         uint    `gorm:"not null;index"`
+	PaymentID         uint    `gorm:"not null"`
+	Provider          string  `gorm:"not null"`
+	ProviderReference string  `gorm:"index;size:191"`
+	Amount            float64 `gorm:"not null"`
+	Status            string  `gorm:"not null;index"`
+	CompletedAt       *time.Time
+}
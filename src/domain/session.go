@@ -0,0 +1,30 @@
+package domain
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Session groups every refresh token issued to one device since login. All
+// tokens of a session belong to the same rotation family.
+type Session struct {
+	gorm.Model
+	UserID        uint `gorm:"not null"`
+	User          User
+	UserAgent     string
+	IPAddress     string
+	LastUsedAt    time.Time `gorm:"not null"`
+	ExpiresAt     time.Time `gorm:"not null"`
+	RevokedAt     *time.Time
+	RefreshTokens []RefreshToken
+}
+
+type RefreshToken struct {
+	gorm.Model
+	SessionID uint `gorm:"not null"`
+	Session   Session
+	TokenHash string    `gorm:"uniqueIndex;size:64;not null"`
+	ExpiresAt time.Time `gorm:"not null"`
+	RotatedAt *time.Time
+}
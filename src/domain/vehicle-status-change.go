@@ -0,0 +1,12 @@
+package domain
+
+import "gorm.io/gorm"
+
+type VehicleStatusChange struct {
+	gorm.Model
+	VehicleID  uint   `gorm:"not null"`
+	ActorID    uint   `gorm:"not null"`
+	FromStatus string `gorm:"not null"`
+	ToStatus   string `gorm:"not null"`
+	Reason     string `gorm:"not null"`
+}
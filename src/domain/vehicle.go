@@ -10,6 +10,9 @@ type Vehicle struct {
 	TransmissionType string  `gorm:"not null"`
 	Year             int     `gorm:"not null"`
 	Type             string  `gorm:"not null"`
+	Branch           string  `gorm:"not null;default:''"`
 	HourlyFare       float64 `gorm:"not null"`
+	RatePlan         `gorm:"embedded"`
 	Bookings         []Booking
+	StatusHistory    []VehicleStatusChange
 }
@@ -0,0 +1,13 @@
+package domain
+
+import "gorm.io/gorm"
+
+// WebhookEvent is an event a payment gateway sent us. Gateways may deliver an
+// event more than once, so processed events are kept to skip repeats.
+type WebhookEvent struct {
+	gorm.Model
+	Provider      string `gorm:"not null;uniqueIndex:idx_webhook_events_provider_event;size:64"`
+	EventID       string `gorm:"not null;uniqueIndex:idx_webhook_events_provider_event;size:191"`
+	Type          string `gorm:"not null"`
+	TransactionID string `gorm:"not null"`
+}
@@ -3,80 +3,162 @@ package handlers
 import (
 	"errors"
 	"net/http"
-	"strconv"
 	"time"
 
+	"backend/src/auth"
 	"backend/src/commons"
 	"backend/src/domain"
 	"backend/src/handlers/requests"
+	"backend/src/policy"
+	"backend/src/pricing"
 
 	"github.com/labstack/echo/v4"
 )
 
 type BookingsService interface {
-	GetAvailableVehicles(from time.Time, to time.Time) ([]domain.Vehicle, error)
-	CreateBooking(request requests.CreateBookingRequest) (*domain.Booking, error)
-	CancelBooking(request requests.CancelBookingRequest) (*domain.Booking, error)
-	ConfirmBooking(request requests.ConfirmBookingRequest) (*domain.Booking, error)
-	FinishBooking(request requests.FinishBookingRequest) (*domain.Booking, error)
-	AddFeedbackBooking(request requests.AddFeedbackBookingRequest) (*domain.Booking, error)
-	RateBooking(request requests.RateBookingRequest) (*domain.Booking, error)
-	AddMessageToBooking(request requests.AddMessageToBookingRequest) (*domain.Booking, error)
-	GetBookingsByUserID(userID uint) ([]domain.Booking, error)
-	GetBookingByID(bookingID uint) (*domain.Booking, error)
-	GetAdminBookings() ([]domain.Booking, error)
+	GetAvailableVehicles(request requests.AvailableVehiclesRequest) ([]domain.Vehicle, int64, error)
+	QuoteBooking(request requests.QuoteBookingRequest) (string, *pricing.Quote, error)
+	CreateBooking(user domain.User, request requests.CreateBookingRequest) (*domain.Booking, error)
+	RescheduleBooking(user domain.User, request requests.RescheduleBookingRequest) (*domain.Booking, error)
+	ExtendBooking(user domain.User, request requests.ExtendBookingRequest) (*domain.Booking, error)
+	ReviewExtension(user domain.User, request requests.ReviewExtensionRequest) (*domain.Booking, error)
+	CancelBooking(user domain.User, request requests.CancelBookingRequest) (*domain.Booking, error)
+	ConfirmBooking(user domain.User, request requests.ConfirmBookingRequest) (*domain.Booking, error)
+	CheckOutBooking(user domain.User, request requests.CheckOutBookingRequest) (*domain.Booking, error)
+	FinishBooking(user domain.User, request requests.FinishBookingRequest) (*domain.Booking, error)
+	ChargeBooking(user domain.User, request requests.ChargeBookingRequest) (*domain.Booking, error)
+	AddFeedbackBooking(user domain.User, request requests.AddFeedbackBookingRequest) (*domain.Booking, error)
+	RateBooking(user domain.User, request requests.RateBookingRequest) (*domain.Booking, error)
+	AddMessageToBooking(user domain.User, request requests.AddMessageToBookingRequest) (*domain.Booking, error)
+	GetBookings(user domain.User, request requests.ListBookingsRequest) ([]domain.Booking, int64, error)
+	GetBookingByID(user domain.User, bookingID uint) (*domain.Booking, error)
+	GetAdminBookings(request requests.ListBookingsRequest) ([]domain.Booking, int64, error)
 }
 
 type BookingsHandler struct {
-	service BookingsService
+	service      BookingsService
+	authenticate echo.MiddlewareFunc
 }
 
-func NewBookingsHandler(service BookingsService) *BookingsHandler {
+func NewBookingsHandler(service BookingsService, authenticate echo.MiddlewareFunc) *BookingsHandler {
 	return &BookingsHandler{
-		service: service,
+		service:      service,
+		authenticate: authenticate,
 	}
 }
 
 func (h *BookingsHandler) AddRoutes(router *echo.Router) {
-	router.Add(echo.GET, "/bookings", h.getBookings)
-	router.Add(echo.GET, "/bookings/admin", h.getAdminBookings)
+	router.Add(echo.GET, "/bookings", h.authenticate(h.getBookings))
+	router.Add(echo.GET, "/bookings/admin", h.authenticate(policy.Require(policy.BookingsReadAny)(h.getAdminBookings)))
 	router.Add(echo.GET, "/bookings/available-vehicles", h.getAvailableVehicles)
-	router.Add(echo.POST, "/bookings", h.createBooking)
-	router.Add(echo.POST, "/bookings/message", h.addMessageToBooking)
-	router.Add(echo.PATCH, "/bookings/cancel", h.cancelBooking)
-	router.Add(echo.PATCH, "/bookings/confirm", h.confirmBooking)
-	router.Add(echo.PATCH, "/bookings/finish", h.finishBooking)
-	router.Add(echo.PATCH, "/bookings/feedback", h.addFeedbackBooking)
-	router.Add(echo.PATCH, "/bookings/rate", h.rateBooking)
+	router.Add(echo.POST, "/bookings", h.authenticate(policy.Require(policy.BookingsCreate)(h.createBooking)))
+	router.Add(echo.POST, "/bookings/quote", h.quoteBooking)
+	router.Add(echo.POST, "/bookings/message", h.authenticate(h.addMessageToBooking))
+	router.Add(echo.PATCH, "/bookings/cancel", h.authenticate(h.cancelBooking))
+	router.Add(echo.PATCH, "/bookings/confirm", h.authenticate(h.confirmBooking))
+	router.Add(echo.PATCH, "/bookings/check-out", h.authenticate(policy.Require(policy.BookingsPickUpAny)(h.checkOutBooking)))
+	router.Add(echo.PATCH, "/bookings/finish", h.authenticate(policy.Require(policy.BookingsFinishAny)(h.finishBooking)))
+	router.Add(echo.PATCH, "/bookings/feedback", h.authenticate(h.addFeedbackBooking))
+	router.Add(echo.PATCH, "/bookings/rate", h.authenticate(h.rateBooking))
+	router.Add(echo.PATCH, "/bookings/:id", h.authenticate(h.rescheduleBooking))
+	router.Add(echo.POST, "/bookings/:id/extensions", h.authenticate(h.extendBooking))
+	router.Add(echo.PATCH, "/bookings/:id/extensions/:extension_id", h.authenticate(policy.Require(policy.BookingsReviewExtensionAny)(h.reviewExtension)))
+	router.Add(echo.POST, "/bookings/:id/charges", h.authenticate(policy.Require(policy.BookingsChargeAny)(h.chargeBooking)))
 }
 
 func (h *BookingsHandler) getAvailableVehicles(c echo.Context) error {
-	from, err := time.Parse(time.RFC3339, c.QueryParam("from"))
-	if err != nil {
+	r := new(requests.AvailableVehiclesRequest)
+	if err := c.Bind(r); err != nil {
 		return c.JSON(http.StatusBadRequest, echo.Map{
-			"message": "invalid from date",
+			"message": err.Error(),
 		})
 	}
-	to, err := time.Parse(time.RFC3339, c.QueryParam("to"))
-	if err != nil {
+
+	if err := c.Validate(r); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"message": err.Error(),
+		})
+	}
+
+	if !r.To.After(r.From) {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"message": "from date must be before to date",
+		})
+	}
+
+	if r.MinYear != 0 && r.MaxYear != 0 && r.MinYear > r.MaxYear {
 		return c.JSON(http.StatusBadRequest, echo.Map{
-			"message": "invalid to date",
+			"message": "min year cannot be greater than max year",
 		})
 	}
 
-	vehicles, err := h.service.GetAvailableVehicles(from, to)
+	vehicles, total, err := h.service.GetAvailableVehicles(*r)
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, echo.Map{
 			"message": "error getting available vehicles",
 		})
 	}
 
-	response := make([]*requests.AvailableVehiclesResponse, 0)
+	items := make([]*requests.AvailableVehiclesResponse, 0)
 	for _, vehicle := range vehicles {
-		response = append(response, mapVehicleToResponse(vehicle))
+		items = append(items, mapVehicleToResponse(vehicle))
 	}
 
-	return c.JSON(http.StatusOK, response)
+	return c.JSON(http.StatusOK, requests.NewListResponse(items, total, r.Pagination))
+}
+
+func (h *BookingsHandler) quoteBooking(c echo.Context) error {
+	r := new(requests.QuoteBookingRequest)
+	if err := c.Bind(r); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"message": err.Error(),
+		})
+	}
+
+	if err := c.Validate(r); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"message": err.Error(),
+		})
+	}
+
+	if r.StartDate.Before(time.Now()) {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"message": "start date cannot be in the past",
+		})
+	}
+
+	if !r.EndDate.After(r.StartDate) {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"message": "start date must be before end date",
+		})
+	}
+
+	id, quote, err := h.service.QuoteBooking(*r)
+	if err != nil {
+		if errors.Is(err, commons.ErrVehicleNotAvailable) || errors.Is(err, commons.ErrBookingTooShort) {
+			return c.JSON(http.StatusBadRequest, echo.Map{
+				"message": err.Error(),
+			})
+		}
+
+		if errors.Is(err, commons.ErrInvalidPromoCode) {
+			return c.JSON(http.StatusBadRequest, echo.Map{
+				"message": err.Error(),
+			})
+		}
+
+		if errors.Is(err, commons.ErrVehicleNotFound) {
+			return c.JSON(http.StatusNotFound, echo.Map{
+				"message": err.Error(),
+			})
+		}
+
+		return c.JSON(http.StatusInternalServerError, echo.Map{
+			"message": err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, mapQuoteToResponse(id, *quote))
 }
 
 func (h *BookingsHandler) createBooking(c echo.Context) error {
@@ -99,15 +181,189 @@ func (h *BookingsHandler) createBooking(c echo.Context) error {
 		})
 	}
 
-	if r.StartDate.After(r.EndDate) {
+	if !r.EndDate.After(r.StartDate) {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"message": "start date must be before end date",
+		})
+	}
+
+	booking, err := h.service.CreateBooking(*auth.Principal(c), *r)
+	if err != nil {
+		if errors.Is(err, commons.ErrVehicleNotAvailable) || errors.Is(err, commons.ErrBookingTooShort) {
+			return c.JSON(http.StatusBadRequest, echo.Map{
+				"message": err.Error(),
+			})
+		}
+
+		if errors.Is(err, commons.ErrInvalidQuote) || errors.Is(err, commons.ErrQuoteMismatch) {
+			return c.JSON(http.StatusBadRequest, echo.Map{
+				"message": err.Error(),
+			})
+		}
+
+		if errors.Is(err, commons.ErrInvalidPromoCode) {
+			return c.JSON(http.StatusBadRequest, echo.Map{
+				"message": err.Error(),
+			})
+		}
+
+		if errors.Is(err, commons.ErrPromotionExhausted) {
+			return c.JSON(http.StatusConflict, echo.Map{
+				"message": err.Error(),
+			})
+		}
+
+		if errors.Is(err, commons.ErrVehicleNotFound) {
+			return c.JSON(http.StatusNotFound, echo.Map{
+				"message": err.Error(),
+			})
+		}
+
+		return c.JSON(http.StatusInternalServerError, echo.Map{
+			"message": err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusCreated, mapBookingToResponse(*booking))
+}
+
+func (h *BookingsHandler) rescheduleBooking(c echo.Context) error {
+	r := new(requests.RescheduleBookingRequest)
+	if err := c.Bind(r); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"message": err.Error(),
+		})
+	}
+
+	if err := c.Validate(r); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"message": err.Error(),
+		})
+	}
+
+	if r.StartDate.Before(time.Now()) {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"message": "start date cannot be in the past",
+		})
+	}
+
+	if !r.EndDate.After(r.StartDate) {
 		return c.JSON(http.StatusBadRequest, echo.Map{
-			"message": "start date cannot be after end date",
+			"message": "start date must be before end date",
 		})
 	}
 
-	booking, err := h.service.CreateBooking(*r)
+	booking, err := h.service.RescheduleBooking(*auth.Principal(c), *r)
 	if err != nil {
+		if errors.Is(err, commons.ErrForbidden) {
+			return c.JSON(http.StatusForbidden, echo.Map{
+				"message": err.Error(),
+			})
+		}
+
+		if errors.Is(err, commons.ErrBookingNotFound) || errors.Is(err, commons.ErrVehicleNotFound) {
+			return c.JSON(http.StatusNotFound, echo.Map{
+				"message": err.Error(),
+			})
+		}
+
 		if errors.Is(err, commons.ErrVehicleNotAvailable) {
+			return c.JSON(http.StatusConflict, echo.Map{
+				"message": err.Error(),
+			})
+		}
+
+		if errors.Is(err, commons.ErrPaymentDeclined) {
+			return c.JSON(http.StatusPaymentRequired, echo.Map{
+				"message": err.Error(),
+			})
+		}
+
+		if errors.Is(err, commons.ErrBookingNotReschedulable) || errors.Is(err, commons.ErrBookingTooShort) {
+			return c.JSON(http.StatusBadRequest, echo.Map{
+				"message": err.Error(),
+			})
+		}
+
+		return c.JSON(http.StatusInternalServerError, echo.Map{
+			"message": err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, mapBookingToResponse(*booking))
+}
+
+func (h *BookingsHandler) extendBooking(c echo.Context) error {
+	r := new(requests.ExtendBookingRequest)
+	if err := c.Bind(r); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"message": err.Error(),
+		})
+	}
+
+	if err := c.Validate(r); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"message": err.Error(),
+		})
+	}
+
+	booking, err := h.service.ExtendBooking(*auth.Principal(c), *r)
+	if err != nil {
+		if errors.Is(err, commons.ErrForbidden) {
+			return c.JSON(http.StatusForbidden, echo.Map{
+				"message": err.Error(),
+			})
+		}
+
+		if errors.Is(err, commons.ErrBookingNotFound) {
+			return c.JSON(http.StatusNotFound, echo.Map{
+				"message": err.Error(),
+			})
+		}
+
+		if errors.Is(err, commons.ErrVehicleNotAvailable) || errors.Is(err, commons.ErrExtensionAlreadyPending) {
+			return c.JSON(http.StatusConflict, echo.Map{
+				"message": err.Error(),
+			})
+		}
+
+		if errors.Is(err, commons.ErrBookingNotExtendable) || errors.Is(err, commons.ErrInvalidExtension) {
+			return c.JSON(http.StatusBadRequest, echo.Map{
+				"message": err.Error(),
+			})
+		}
+
+		return c.JSON(http.StatusInternalServerError, echo.Map{
+			"message": err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusCreated, mapBookingToResponse(*booking))
+}
+
+func (h *BookingsHandler) chargeBooking(c echo.Context) error {
+	r := new(requests.ChargeBookingRequest)
+	if err := c.Bind(r); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"message": err.Error(),
+		})
+	}
+
+	if err := c.Validate(r); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"message": err.Error(),
+		})
+	}
+
+	booking, err := h.service.ChargeBooking(*auth.Principal(c), *r)
+	if err != nil {
+		if errors.Is(err, commons.ErrBookingNotFound) {
+			return c.JSON(http.StatusNotFound, echo.Map{
+				"message": err.Error(),
+			})
+		}
+
+		if errors.Is(err, commons.ErrBookingNotChargeable) {
 			return c.JSON(http.StatusBadRequest, echo.Map{
 				"message": err.Error(),
 			})
@@ -121,6 +377,48 @@ func (h *BookingsHandler) createBooking(c echo.Context) error {
 	return c.JSON(http.StatusCreated, mapBookingToResponse(*booking))
 }
 
+func (h *BookingsHandler) reviewExtension(c echo.Context) error {
+	r := new(requests.ReviewExtensionRequest)
+	if err := c.Bind(r); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"message": err.Error(),
+		})
+	}
+
+	if err := c.Validate(r); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"message": err.Error(),
+		})
+	}
+
+	booking, err := h.service.ReviewExtension(*auth.Principal(c), *r)
+	if err != nil {
+		if errors.Is(err, commons.ErrBookingNotFound) || errors.Is(err, commons.ErrExtensionNotFound) {
+			return c.JSON(http.StatusNotFound, echo.Map{
+				"message": err.Error(),
+			})
+		}
+
+		if errors.Is(err, commons.ErrVehicleNotAvailable) || errors.Is(err, commons.ErrExtensionNotPending) {
+			return c.JSON(http.StatusConflict, echo.Map{
+				"message": err.Error(),
+			})
+		}
+
+		if errors.Is(err, commons.ErrBookingNotExtendable) {
+			return c.JSON(http.StatusBadRequest, echo.Map{
+				"message": err.Error(),
+			})
+		}
+
+		return c.JSON(http.StatusInternalServerError, echo.Map{
+			"message": err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, mapBookingToResponse(*booking))
+}
+
 func (h *BookingsHandler) cancelBooking(c echo.Context) error {
 	r := new(requests.CancelBookingRequest)
 	if err := c.Bind(r); err != nil {
@@ -135,10 +433,10 @@ func (h *BookingsHandler) cancelBooking(c echo.Context) error {
 		})
 	}
 
-	booking, err := h.service.CancelBooking(*r)
+	booking, err := h.service.CancelBooking(*auth.Principal(c), *r)
 	if err != nil {
-		if errors.Is(err, commons.ErrInvalidCredentials) {
-			return c.JSON(http.StatusUnauthorized, echo.Map{
+		if errors.Is(err, commons.ErrForbidden) {
+			return c.JSON(http.StatusForbidden, echo.Map{
 				"message": err.Error(),
 			})
 		}
@@ -167,6 +465,30 @@ func (h *BookingsHandler) cancelBooking(c echo.Context) error {
 			})
 		}
 
+		if errors.Is(err, commons.ErrInvalidBookingTransition) {
+			return c.JSON(http.StatusBadRequest, echo.Map{
+				"message": err.Error(),
+			})
+		}
+
+		if errors.Is(err, commons.ErrBookingAlreadyConfirmed) {
+			return c.JSON(http.StatusBadRequest, echo.Map{
+				"message": err.Error(),
+			})
+		}
+
+		if errors.Is(err, commons.ErrBookingExpired) || errors.Is(err, commons.ErrBookingNoShow) {
+			return c.JSON(http.StatusBadRequest, echo.Map{
+				"message": err.Error(),
+			})
+		}
+
+		if errors.Is(err, commons.ErrBookingStatusChanged) {
+			return c.JSON(http.StatusConflict, echo.Map{
+				"message": err.Error(),
+			})
+		}
+
 		return c.JSON(http.StatusInternalServerError, echo.Map{
 			"message": err.Error(),
 		})
@@ -188,10 +510,99 @@ func (h *BookingsHandler) confirmBooking(c echo.Context) error {
 		})
 	}
 
-	booking, err := h.service.ConfirmBooking(*r)
+	booking, err := h.service.ConfirmBooking(*auth.Principal(c), *r)
+	if err != nil {
+		if errors.Is(err, commons.ErrForbidden) {
+			return c.JSON(http.StatusForbidden, echo.Map{
+				"message": err.Error(),
+			})
+		}
+
+		if errors.Is(err, commons.ErrPaymentDeclined) {
+			return c.JSON(http.StatusPaymentRequired, echo.Map{
+				"message": err.Error(),
+			})
+		}
+
+		if errors.Is(err, commons.ErrBookingNotFound) {
+			return c.JSON(http.StatusNotFound, echo.Map{
+				"message": err.Error(),
+			})
+		}
+
+		if errors.Is(err, commons.ErrBookingAlreadyCancelled) {
+			return c.JSON(http.StatusBadRequest, echo.Map{
+				"message": err.Error(),
+			})
+		}
+
+		if errors.Is(err, commons.ErrBookingAlreadyFinished) {
+			return c.JSON(http.StatusBadRequest, echo.Map{
+				"message": err.Error(),
+			})
+		}
+
+		if errors.Is(err, commons.ErrBookingAlreadyStarted) {
+			return c.JSON(http.StatusBadRequest, echo.Map{
+				"message": err.Error(),
+			})
+		}
+
+		if errors.Is(err, commons.ErrInvalidBookingTransition) {
+			return c.JSON(http.StatusBadRequest, echo.Map{
+				"message": err.Error(),
+			})
+		}
+
+		if errors.Is(err, commons.ErrBookingDatesPassed) {
+			return c.JSON(http.StatusBadRequest, echo.Map{
+				"message": err.Error(),
+			})
+		}
+
+		if errors.Is(err, commons.ErrBookingAlreadyConfirmed) {
+			return c.JSON(http.StatusBadRequest, echo.Map{
+				"message": err.Error(),
+			})
+		}
+
+		if errors.Is(err, commons.ErrBookingExpired) || errors.Is(err, commons.ErrBookingNoShow) {
+			return c.JSON(http.StatusBadRequest, echo.Map{
+				"message": err.Error(),
+			})
+		}
+
+		if errors.Is(err, commons.ErrBookingStatusChanged) {
+			return c.JSON(http.StatusConflict, echo.Map{
+				"message": err.Error(),
+			})
+		}
+
+		return c.JSON(http.StatusInternalServerError, echo.Map{
+			"message": err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, mapBookingToResponse(*booking))
+}
+
+func (h *BookingsHandler) checkOutBooking(c echo.Context) error {
+	r := new(requests.CheckOutBookingRequest)
+	if err := c.Bind(r); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"message": err.Error(),
+		})
+	}
+	if err := c.Validate(r); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"message": err.Error(),
+		})
+	}
+
+	booking, err := h.service.CheckOutBooking(*auth.Principal(c), *r)
 	if err != nil {
-		if errors.Is(err, commons.ErrInvalidCredentials) {
-			return c.JSON(http.StatusUnauthorized, echo.Map{
+		if errors.Is(err, commons.ErrForbidden) {
+			return c.JSON(http.StatusForbidden, echo.Map{
 				"message": err.Error(),
 			})
 		}
@@ -220,6 +631,36 @@ func (h *BookingsHandler) confirmBooking(c echo.Context) error {
 			})
 		}
 
+		if errors.Is(err, commons.ErrBookingNotConfirmed) {
+			return c.JSON(http.StatusBadRequest, echo.Map{
+				"message": err.Error(),
+			})
+		}
+
+		if errors.Is(err, commons.ErrBookingDatesPassed) {
+			return c.JSON(http.StatusBadRequest, echo.Map{
+				"message": err.Error(),
+			})
+		}
+
+		if errors.Is(err, commons.ErrInvalidBookingTransition) {
+			return c.JSON(http.StatusBadRequest, echo.Map{
+				"message": err.Error(),
+			})
+		}
+
+		if errors.Is(err, commons.ErrBookingExpired) || errors.Is(err, commons.ErrBookingNoShow) {
+			return c.JSON(http.StatusBadRequest, echo.Map{
+				"message": err.Error(),
+			})
+		}
+
+		if errors.Is(err, commons.ErrBookingStatusChanged) {
+			return c.JSON(http.StatusConflict, echo.Map{
+				"message": err.Error(),
+			})
+		}
+
 		return c.JSON(http.StatusInternalServerError, echo.Map{
 			"message": err.Error(),
 		})
@@ -241,10 +682,10 @@ func (h *BookingsHandler) finishBooking(c echo.Context) error {
 		})
 	}
 
-	booking, err := h.service.FinishBooking(*r)
+	booking, err := h.service.FinishBooking(*auth.Principal(c), *r)
 	if err != nil {
-		if errors.Is(err, commons.ErrInvalidCredentials) {
-			return c.JSON(http.StatusUnauthorized, echo.Map{
+		if errors.Is(err, commons.ErrForbidden) {
+			return c.JSON(http.StatusForbidden, echo.Map{
 				"message": err.Error(),
 			})
 		}
@@ -273,6 +714,30 @@ func (h *BookingsHandler) finishBooking(c echo.Context) error {
 			})
 		}
 
+		if errors.Is(err, commons.ErrInvalidBookingTransition) {
+			return c.JSON(http.StatusBadRequest, echo.Map{
+				"message": err.Error(),
+			})
+		}
+
+		if errors.Is(err, commons.ErrInvalidOdometer) {
+			return c.JSON(http.StatusBadRequest, echo.Map{
+				"message": err.Error(),
+			})
+		}
+
+		if errors.Is(err, commons.ErrBookingExpired) || errors.Is(err, commons.ErrBookingNoShow) {
+			return c.JSON(http.StatusBadRequest, echo.Map{
+				"message": err.Error(),
+			})
+		}
+
+		if errors.Is(err, commons.ErrBookingStatusChanged) {
+			return c.JSON(http.StatusConflict, echo.Map{
+				"message": err.Error(),
+			})
+		}
+
 		return c.JSON(http.StatusInternalServerError, echo.Map{
 			"message": err.Error(),
 		})
@@ -294,10 +759,10 @@ func (h *BookingsHandler) addFeedbackBooking(c echo.Context) error {
 		})
 	}
 
-	booking, err := h.service.AddFeedbackBooking(*r)
+	booking, err := h.service.AddFeedbackBooking(*auth.Principal(c), *r)
 	if err != nil {
-		if errors.Is(err, commons.ErrInvalidCredentials) {
-			return c.JSON(http.StatusUnauthorized, echo.Map{
+		if errors.Is(err, commons.ErrForbidden) {
+			return c.JSON(http.StatusForbidden, echo.Map{
 				"message": err.Error(),
 			})
 		}
@@ -347,10 +812,10 @@ func (h *BookingsHandler) rateBooking(c echo.Context) error {
 		})
 	}
 
-	booking, err := h.service.RateBooking(*r)
+	booking, err := h.service.RateBooking(*auth.Principal(c), *r)
 	if err != nil {
-		if errors.Is(err, commons.ErrInvalidCredentials) {
-			return c.JSON(http.StatusUnauthorized, echo.Map{
+		if errors.Is(err, commons.ErrForbidden) {
+			return c.JSON(http.StatusForbidden, echo.Map{
 				"message": err.Error(),
 			})
 		}
@@ -394,10 +859,10 @@ func (h *BookingsHandler) addMessageToBooking(c echo.Context) error {
 		})
 	}
 
-	booking, err := h.service.AddMessageToBooking(*r)
+	booking, err := h.service.AddMessageToBooking(*auth.Principal(c), *r)
 	if err != nil {
-		if errors.Is(err, commons.ErrInvalidCredentials) {
-			return c.JSON(http.StatusUnauthorized, echo.Map{
+		if errors.Is(err, commons.ErrForbidden) {
+			return c.JSON(http.StatusForbidden, echo.Map{
 				"message": err.Error(),
 			})
 		}
@@ -417,86 +882,83 @@ func (h *BookingsHandler) addMessageToBooking(c echo.Context) error {
 }
 
 func (h *BookingsHandler) getBookings(c echo.Context) error {
-	// Tiene prioridad la busqueda de booking por ID
-	bookingIDStr := c.QueryParam("booking_id")
-	userIDStr := c.QueryParam("user_id")
+	principal := auth.Principal(c)
 
-	if bookingIDStr == "" && userIDStr == "" {
+	r := new(requests.ListBookingsRequest)
+	if err := c.Bind(r); err != nil {
 		return c.JSON(http.StatusBadRequest, echo.Map{
-			"message": "invalid query params, required booking_id or user_id",
+			"message": err.Error(),
 		})
 	}
 
-	if bookingIDStr != "" {
-		bookingID, err := strconv.ParseUint(bookingIDStr, 10, 32)
-		if err != nil {
-			return c.JSON(http.StatusBadRequest, echo.Map{
-				"message": "invalid booking id",
-			})
-		}
+	if err := c.Validate(r); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"message": err.Error(),
+		})
+	}
 
-		if bookingID != 0 {
-			booking, err := h.service.GetBookingByID(uint(bookingID))
-			if err != nil {
-				return c.JSON(http.StatusInternalServerError, echo.Map{
+	// Tiene prioridad la busqueda de booking por ID
+	if r.BookingID != 0 {
+		booking, err := h.service.GetBookingByID(*principal, r.BookingID)
+		if err != nil {
+			if errors.Is(err, commons.ErrBookingNotFound) {
+				return c.JSON(http.StatusNotFound, echo.Map{
 					"message": err.Error(),
 				})
 			}
 
-			if booking == nil {
-				return c.JSON(http.StatusNotFound, echo.Map{
-					"message": "booking not found",
+			if errors.Is(err, commons.ErrForbidden) {
+				return c.JSON(http.StatusForbidden, echo.Map{
+					"message": err.Error(),
 				})
 			}
 
-			return c.JSON(http.StatusOK, mapBookingToResponse(*booking))
+			return c.JSON(http.StatusInternalServerError, echo.Map{
+				"message": err.Error(),
+			})
 		}
+
+		return c.JSON(http.StatusOK, mapBookingToResponse(*booking))
 	}
 
-	if userIDStr != "" {
-		userID, err := strconv.ParseUint(userIDStr, 10, 32)
-		if err != nil {
-			return c.JSON(http.StatusBadRequest, echo.Map{
-				"message": "invalid user id",
+	bookings, total, err := h.service.GetBookings(*principal, *r)
+	if err != nil {
+		if errors.Is(err, commons.ErrForbidden) {
+			return c.JSON(http.StatusForbidden, echo.Map{
+				"message": err.Error(),
 			})
 		}
 
-		if userID != 0 {
-			bookings, err := h.service.GetBookingsByUserID(uint(userID))
-			if err != nil {
-				return c.JSON(http.StatusInternalServerError, echo.Map{
-					"message": err.Error(),
-				})
-			}
-
-			response := make([]*requests.BookingResponse, 0)
-			for _, booking := range bookings {
-				response = append(response, mapBookingToResponse(booking))
-			}
-
-			return c.JSON(http.StatusOK, response)
-		}
+		return c.JSON(http.StatusInternalServerError, echo.Map{
+			"message": err.Error(),
+		})
 	}
 
-	return c.JSON(http.StatusBadRequest, echo.Map{
-		"message": "invalid query params",
-	})
+	return c.JSON(http.StatusOK, mapBookingsToListResponse(bookings, total, r.Pagination))
 }
 
 func (h *BookingsHandler) getAdminBookings(c echo.Context) error {
-	bookings, err := h.service.GetAdminBookings()
-	if err != nil {
-		return c.JSON(http.StatusInternalServerError, echo.Map{
+	r := new(requests.ListBookingsRequest)
+	if err := c.Bind(r); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{
 			"message": err.Error(),
 		})
 	}
 
-	response := make([]*requests.BookingResponse, 0)
-	for _, booking := range bookings {
-		response = append(response, mapBookingToResponse(booking))
+	if err := c.Validate(r); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"message": err.Error(),
+		})
 	}
 
-	return c.JSON(http.StatusOK, response)
+	bookings, total, err := h.service.GetAdminBookings(*r)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{
+			"message": err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, mapBookingsToListResponse(bookings, total, r.Pagination))
 }
 
 func mapVehicleToResponse(vehicle domain.Vehicle) *requests.AvailableVehiclesResponse {
@@ -508,7 +970,9 @@ func mapVehicleToResponse(vehicle domain.Vehicle) *requests.AvailableVehiclesRes
 		TransmissionType: vehicle.TransmissionType,
 		Year:             vehicle.Year,
 		Type:             vehicle.Type,
+		Branch:           vehicle.Branch,
 		HourlyFare:       vehicle.HourlyFare,
+		RatePlan:         *mapRatePlanToResponse(vehicle.RatePlan),
 	}
 }
 
@@ -523,13 +987,58 @@ func mapMessageToResponse(message domain.BookingMessage) *requests.MessagesRespo
 
 func mapBookingToResponse(booking domain.Booking) *requests.BookingResponse {
 	vehicle := mapVehicleToResponse(booking.Vehicle)
-	totalAmount := booking.EndDate.Sub(booking.StartDate).Hours() * booking.HourlyFare
+	// Bookings made before line items existed were never priced
+	totalAmount := booking.TotalAmount
+	if len(booking.LineItems) == 0 {
+		start, end := billablePeriod(booking)
+		totalAmount = end.Sub(start).Hours() * booking.HourlyFare
+	}
+
+	lineItems := make([]requests.LineItemResponse, 0)
+	for _, item := range booking.LineItems {
+		lineItems = append(lineItems, *mapLineItemToResponse(item))
+	}
 
 	messages := make([]requests.MessagesResponse, 0)
 	for _, message := range booking.Messages {
 		messages = append(messages, *mapMessageToResponse(message))
 	}
 
+	history := make([]requests.StatusChangeResponse, 0)
+	for _, change := range booking.StatusChanges {
+		history = append(history, *mapStatusChangeToResponse(change))
+	}
+
+	extensions := make([]requests.ExtensionResponse, 0)
+	for _, extension := range booking.Extensions {
+		extensions = append(extensions, *mapExtensionToResponse(extension))
+	}
+
+	payments := make([]requests.PaymentResponse, 0)
+	for _, payment := range booking.Payments {
+		payments = append(payments, *mapPaymentToResponse(payment))
+	}
+
+	charges := make([]requests.ChargeResponse, 0)
+	for _, charge := range booking.Charges {
+		charges = append(charges, *mapChargeToResponse(charge))
+	}
+
+	var settlement *requests.SettlementResponse
+	if booking.Settlement != nil {
+		settlement = mapSettlementToResponse(*booking.Settlement)
+	}
+
+	refunds := make([]requests.RefundResponse, 0)
+	for _, refund := range booking.Refunds {
+		refunds = append(refunds, *mapRefundToResponse(refund))
+	}
+
+	var promoCode *string
+	if booking.Promotion != nil {
+		promoCode = &booking.Promotion.Code
+	}
+
 	return &requests.BookingResponse{
 		ID:              booking.ID,
 		CreatedAt:       booking.CreatedAt,
@@ -546,6 +1055,172 @@ func mapBookingToResponse(booking domain.Booking) *requests.BookingResponse {
 		DropOffLocation: booking.DropOffLocation,
 		HourlyFare:      booking.HourlyFare,
 		TotalAmount:     totalAmount,
+		DiscountAmount:  booking.DiscountAmount,
+		PromoCode:       promoCode,
+		TaxAmount:       booking.TaxAmount,
+		DepositAmount:   booking.DepositAmount,
+		CancellationFee: booking.CancellationFee,
+		ActualPickUpAt:  booking.ActualPickUpAt,
+		PickUpOdometer:  booking.PickUpOdometer,
+		PickUpFuelLevel: booking.PickUpFuelLevel,
+		ActualReturnAt:  booking.ActualReturnAt,
+		ReturnOdometer:  booking.ReturnOdometer,
+		ReturnFuelLevel: booking.ReturnFuelLevel,
 		Messages:        messages,
+		History:         history,
+		Extensions:      extensions,
+		LineItems:       lineItems,
+		Payments:        payments,
+		Charges:         charges,
+		Settlement:      settlement,
+		Refunds:         refunds,
+		RefundStatus:    refundStatus(booking.Refunds),
+	}
+}
+
+func mapQuoteToResponse(id string, quote pricing.Quote) *requests.QuoteResponse {
+	lineItems := make([]requests.LineItemResponse, 0)
+	for _, line := range quote.Breakdown.Lines {
+		lineItems = append(lineItems, requests.LineItemResponse{
+			Description: line.Description,
+			Quantity:    line.Quantity,
+			UnitPrice:   line.UnitPrice,
+			Amount:      line.Amount,
+		})
+	}
+
+	return &requests.QuoteResponse{
+		QuoteID:        id,
+		ExpiresAt:      quote.ExpiresAt,
+		VehicleID:      quote.VehicleID,
+		StartDate:      quote.StartDate,
+		EndDate:        quote.EndDate,
+		LineItems:      lineItems,
+		Subtotal:       quote.Breakdown.Subtotal,
+		DiscountAmount: quote.Breakdown.Discount,
+		TaxAmount:      quote.Breakdown.Tax,
+		TotalAmount:    quote.Breakdown.Total,
+		Deposit:        quote.Deposit,
+	}
+}
+
+func mapLineItemToResponse(item domain.BookingLineItem) *requests.LineItemResponse {
+	return &requests.LineItemResponse{
+		Description: item.Description,
+		Quantity:    item.Quantity,
+		UnitPrice:   item.UnitPrice,
+		Amount:      item.Amount,
+	}
+}
+
+func mapPaymentToResponse(payment domain.Payment) *requests.PaymentResponse {
+	return &requests.PaymentResponse{
+		ID:             payment.ID,
+		CreatedAt:      payment.CreatedAt,
+		Provider:       payment.Provider,
+		Kind:           payment.Kind,
+		Status:         payment.Status,
+		Amount:         payment.Amount,
+		CapturedAmount: payment.CapturedAmount,
+	}
+}
+
+func mapChargeToResponse(charge domain.BookingCharge) *requests.ChargeResponse {
+	return &requests.ChargeResponse{
+		ID:          charge.ID,
+		CreatedAt:   charge.CreatedAt,
+		Kind:        charge.Kind,
+		Description: charge.Description,
+		Amount:      charge.Amount,
+		PostedByID:  charge.PostedByID,
 	}
 }
+
+func mapSettlementToResponse(settlement domain.DepositSettlement) *requests.SettlementResponse {
+	return &requests.SettlementResponse{
+		HeldAmount:         settlement.HeldAmount,
+		ChargedAmount:      settlement.ChargedAmount,
+		CapturedAmount:     settlement.CapturedAmount,
+		ReleasedAmount:     settlement.ReleasedAmount,
+		ExtraChargedAmount: settlement.ExtraChargedAmount,
+		SettledAt:          settlement.SettledAt,
+	}
+}
+
+func mapRefundToResponse(refund domain.Refund) *requests.RefundResponse {
+	return &requests.RefundResponse{
+		ID:          refund.ID,
+		CreatedAt:   refund.CreatedAt,
+		PaymentID:   refund.PaymentID,
+		Amount:      refund.Amount,
+		Status:      refund.Status,
+		CompletedAt: refund.CompletedAt,
+	}
+}
+
+// refundStatus sums up the refunds of a booking: failed if any failed, pending
+// while any is pending and completed once all are. It is nil when nothing was
+// refunded.
+func refundStatus(refunds []domain.Refund) *string {
+	if len(refunds) == 0 {
+		return nil
+	}
+
+	status := commons.RefundStatusCompleted
+	for _, refund := range refunds {
+		if refund.Status == commons.RefundStatusFailed {
+			status = commons.RefundStatusFailed
+			break
+		}
+
+		if refund.Status == commons.RefundStatusPending {
+			status = commons.RefundStatusPending
+		}
+	}
+
+	return &status
+}
+
+func mapExtensionToResponse(extension domain.BookingExtension) *requests.ExtensionResponse {
+	return &requests.ExtensionResponse{
+		ID:               extension.ID,
+		CreatedAt:        extension.CreatedAt,
+		Status:           extension.Status,
+		RequestedByID:    extension.RequestedByID,
+		ReviewedByID:     extension.ReviewedByID,
+		PreviousEndDate:  extension.PreviousEndDate,
+		RequestedEndDate: extension.RequestedEndDate,
+		ExtraCost:        extension.ExtraCost,
+	}
+}
+
+func mapStatusChangeToResponse(change domain.BookingStatusChange) *requests.StatusChangeResponse {
+	return &requests.StatusChangeResponse{
+		ID:         change.ID,
+		CreatedAt:  change.CreatedAt,
+		ActorID:    change.ActorID,
+		FromStatus: change.FromStatus,
+		ToStatus:   change.ToStatus,
+		Reason:     change.Reason,
+	}
+}
+
+func mapBookingsToListResponse(bookings []domain.Booking, total int64, pagination requests.Pagination) requests.ListResponse[*requests.BookingResponse] {
+	items := make([]*requests.BookingResponse, 0)
+	for _, booking := range bookings {
+		items = append(items, mapBookingToResponse(booking))
+	}
+
+	return requests.NewListResponse(items, total, pagination)
+}
+
+// billablePeriod uses the actual pick up and return times once the rental has
+// been checked out and back in, and the planned dates otherwise.
+func billablePeriod(booking domain.Booking) (time.Time, time.Time) {
+	start, end := booking.StartDate, booking.EndDate
+	if booking.ActualPickUpAt != nil && booking.ActualReturnAt != nil {
+		start, end = *booking.ActualPickUpAt, *booking.ActualReturnAt
+	}
+
+	return start, end
+}
@@ -0,0 +1,224 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"backend/src/commons"
+	"backend/src/domain"
+	"backend/src/handlers/requests"
+	"backend/src/policy"
+
+	"github.com/labstack/echo/v4"
+)
+
+type PromotionsService interface {
+	GetPromotions() ([]domain.Promotion, error)
+	GetPromotionByID(promotionID uint) (*domain.Promotion, error)
+	CreatePromotion(request requests.CreatePromotionRequest) (*domain.Promotion, error)
+	UpdatePromotion(request requests.UpdatePromotionRequest) (*domain.Promotion, error)
+	DeletePromotion(promotionID uint) error
+}
+
+type PromotionsHandler struct {
+	service      PromotionsService
+	authenticate echo.MiddlewareFunc
+}
+
+func NewPromotionsHandler(service PromotionsService, authenticate echo.MiddlewareFunc) *PromotionsHandler {
+	return &PromotionsHandler{
+		service:      service,
+		authenticate: authenticate,
+	}
+}
+
+func (h *PromotionsHandler) AddRoutes(router *echo.Router) {
+	manage := func(next echo.HandlerFunc) echo.HandlerFunc {
+		return h.authenticate(policy.Require(policy.PromotionsManage)(next))
+	}
+
+	router.Add(echo.GET, "/promotions", manage(h.getPromotions))
+	router.Add(echo.GET, "/promotions/:id", manage(h.getPromotion))
+	router.Add(echo.POST, "/promotions", manage(h.createPromotion))
+	router.Add(echo.PUT, "/promotions/:id", manage(h.updatePromotion))
+	router.Add(echo.DELETE, "/promotions/:id", manage(h.deletePromotion))
+}
+
+func (h *PromotionsHandler) getPromotions(c echo.Context) error {
+	promotions, err := h.service.GetPromotions()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{
+			"message": err.Error(),
+		})
+	}
+
+	response := make([]*requests.PromotionResponse, 0)
+	for _, promotion := range promotions {
+		response = append(response, mapPromotionToResponse(promotion))
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
+
+func (h *PromotionsHandler) getPromotion(c echo.Context) error {
+	promotionID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil || promotionID == 0 {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"message": "invalid promotion id",
+		})
+	}
+
+	promotion, err := h.service.GetPromotionByID(uint(promotionID))
+	if err != nil {
+		if errors.Is(err, commons.ErrPromotionNotFound) {
+			return c.JSON(http.StatusNotFound, echo.Map{
+				"message": err.Error(),
+			})
+		}
+
+		return c.JSON(http.StatusInternalServerError, echo.Map{
+			"message": err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, mapPromotionToResponse(*promotion))
+}
+
+func (h *PromotionsHandler) createPromotion(c echo.Context) error {
+	r := new(requests.CreatePromotionRequest)
+	if err := c.Bind(r); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"message": err.Error(),
+		})
+	}
+
+	if err := c.Validate(r); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"message": err.Error(),
+		})
+	}
+
+	if message := validatePromotionTerms(r.DiscountType, r.DiscountValue, r.ValidFrom.Before(r.ValidUntil)); message != "" {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"message": message,
+		})
+	}
+
+	promotion, err := h.service.CreatePromotion(*r)
+	if err != nil {
+		if errors.Is(err, commons.ErrPromotionAlreadyExists) {
+			return c.JSON(http.StatusConflict, echo.Map{
+				"message": err.Error(),
+			})
+		}
+
+		return c.JSON(http.StatusInternalServerError, echo.Map{
+			"message": err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusCreated, mapPromotionToResponse(*promotion))
+}
+
+func (h *PromotionsHandler) updatePromotion(c echo.Context) error {
+	r := new(requests.UpdatePromotionRequest)
+	if err := c.Bind(r); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"message": err.Error(),
+		})
+	}
+
+	if err := c.Validate(r); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"message": err.Error(),
+		})
+	}
+
+	if message := validatePromotionTerms(r.DiscountType, r.DiscountValue, r.ValidFrom.Before(r.ValidUntil)); message != "" {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"message": message,
+		})
+	}
+
+	promotion, err := h.service.UpdatePromotion(*r)
+	if err != nil {
+		if errors.Is(err, commons.ErrPromotionNotFound) {
+			return c.JSON(http.StatusNotFound, echo.Map{
+				"message": err.Error(),
+			})
+		}
+
+		if errors.Is(err, commons.ErrPromotionAlreadyExists) {
+			return c.JSON(http.StatusConflict, echo.Map{
+				"message": err.Error(),
+			})
+		}
+
+		return c.JSON(http.StatusInternalServerError, echo.Map{
+			"message": err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, mapPromotionToResponse(*promotion))
+}
+
+func (h *PromotionsHandler) deletePromotion(c echo.Context) error {
+	promotionID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil || promotionID == 0 {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"message": "invalid promotion id",
+		})
+	}
+
+	if err := h.service.DeletePromotion(uint(promotionID)); err != nil {
+		if errors.Is(err, commons.ErrPromotionNotFound) {
+			return c.JSON(http.StatusNotFound, echo.Map{
+				"message": err.Error(),
+			})
+		}
+
+		return c.JSON(http.StatusInternalServerError, echo.Map{
+			"message": err.Error(),
+		})
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// validatePromotionTerms returns why the terms of a promotion are invalid, or
+// an empty string if they are fine.
+func validatePromotionTerms(discountType string, discountValue float64, validWindow bool) string {
+	if !validWindow {
+		return "valid from must be before valid until"
+	}
+
+	if discountType == commons.DiscountTypePercentage && discountValue > 100 {
+		return "percentage discount cannot exceed 100"
+	}
+
+	return ""
+}
+
+func mapPromotionToResponse(promotion domain.Promotion) *requests.PromotionResponse {
+	vehicleTypes := promotion.VehicleTypes
+	if vehicleTypes == nil {
+		vehicleTypes = make([]string, 0)
+	}
+
+	return &requests.PromotionResponse{
+		ID:             promotion.ID,
+		CreatedAt:      promotion.CreatedAt,
+		UpdatedAt:      promotion.UpdatedAt,
+		Code:           promotion.Code,
+		Description:    promotion.Description,
+		DiscountType:   promotion.DiscountType,
+		DiscountValue:  promotion.DiscountValue,
+		ValidFrom:      promotion.ValidFrom,
+		ValidUntil:     promotion.ValidUntil,
+		VehicleTypes:   vehicleTypes,
+		MaxUses:        promotion.MaxUses,
+		MaxUsesPerUser: promotion.MaxUsesPerUser,
+		UsesCount:      promotion.UsesCount,
+	}
+}
@@ -2,6 +2,20 @@ package requests
 
 import "time"
 
+type AvailableVehiclesRequest struct {
+	Pagination
+	From             time.Time `query:"from" validate:"required"`
+	To               time.Time `query:"to" validate:"required"`
+	Brand            string    `query:"brand"`
+	Type             string    `query:"type" validate:"omitempty,vehicle_type"`
+	TransmissionType string    `query:"transmission_type" validate:"omitempty,vehicle_transmission"`
+	MinYear          int       `query:"min_year" validate:"omitempty,min=0"`
+	MaxYear          int       `query:"max_year" validate:"omitempty,min=0"`
+	MaxHourlyFare    float64   `query:"max_hourly_fare" validate:"omitempty,gt=0"`
+	Branch           string    `query:"branch"`
+	Sort             string    `query:"sort" validate:"omitempty,oneof=hourly_fare -hourly_fare year -year brand -brand"`
+}
+
 type AvailableVehiclesResponse struct {
 	ID               uint    `json:"id"`
 	Status           string  `json:"status"`
@@ -10,16 +24,52 @@ type AvailableVehiclesResponse struct {
 	TransmissionType string  `json:"transmission_type"`
 	Year             int     `json:"year"`
 	Type             string  `json:"type"`
+	Branch           string  `json:"branch"`
 	HourlyFare       float64 `json:"hourly_fare"`
+	RatePlan
+}
+
+type ListBookingsRequest struct {
+	Pagination
+	BookingID uint      `query:"booking_id"`
+	UserID    uint      `query:"user_id"`
+	Status    string    `query:"status" validate:"omitempty,booking_status"`
+	VehicleID uint      `query:"vehicle_id"`
+	From      time.Time `query:"from"`
+	To        time.Time `query:"to"`
+	Overdue   bool      `query:"overdue"`
+	Sort      string    `query:"sort" validate:"omitempty,oneof=start_date -start_date created_at -created_at"`
 }
 
 type CreateBookingRequest struct {
-	UserID          uint      `json:"user_id" validate:"required"`
 	VehicleID       uint      `json:"vehicle_id" validate:"required"`
 	StartDate       time.Time `json:"start_date" validate:"required"`
 	EndDate         time.Time `json:"end_date" validate:"required"`
 	PickUpLocation  string    `json:"pick_up_location" validate:"required"`
 	DropOffLocation string    `json:"drop_off_location" validate:"required"`
+	QuoteID         string    `json:"quote_id"`
+	PromoCode       string    `json:"promo_code" validate:"omitempty,max=64"`
+}
+
+type QuoteBookingRequest struct {
+	VehicleID uint      `json:"vehicle_id" validate:"required"`
+	StartDate time.Time `json:"start_date" validate:"required"`
+	EndDate   time.Time `json:"end_date" validate:"required"`
+	PromoCode string    `json:"promo_code" validate:"omitempty,max=64"`
+}
+
+type QuoteResponse struct {
+	QuoteID        string             `json:"quote_id"`
+	ExpiresAt      time.Time          `json:"expires_at"`
+	VehicleID      uint               `json:"vehicle_id"`
+	StartDate      time.Time          `json:"start_date"`
+	EndDate        time.Time          `json:"end_date"`
+	LineItems      []LineItemResponse `json:"line_items"`
+	Subtotal       float64            `json:"subtotal"`
+	DiscountAmount float64            `json:"discount_amount"`
+	TaxAmount      float64            `json:"tax_amount"`
+	TotalAmount    float64            `json:"total_amount"`
+	Deposit        float64            `json:"deposit"`
 }
 
 type BookingResponse struct {
@@ -38,7 +88,52 @@ type BookingResponse struct {
 	DropOffLocation string                    `json:"drop_off_location"`
 	HourlyFare      float64                   `json:"hourly_fare"`
 	TotalAmount     float64                   `json:"total_amount"`
+	DiscountAmount  float64                   `json:"discount_amount"`
+	PromoCode       *string                   `json:"promo_code"`
+	TaxAmount       float64                   `json:"tax_amount"`
+	DepositAmount   float64                   `json:"deposit_amount"`
+	CancellationFee float64                   `json:"cancellation_fee"`
+	ActualPickUpAt  *time.Time                `json:"actual_pick_up_at"`
+	PickUpOdometer  *int                      `json:"pick_up_odometer"`
+	PickUpFuelLevel *int                      `json:"pick_up_fuel_level"`
+	ActualReturnAt  *time.Time                `json:"actual_return_at"`
+	ReturnOdometer  *int                      `json:"return_odometer"`
+	ReturnFuelLevel *int                      `json:"return_fuel_level"`
 	Messages        []MessagesResponse        `json:"messages"`
+	History         []StatusChangeResponse    `json:"history"`
+	Extensions      []ExtensionResponse       `json:"extensions"`
+	LineItems       []LineItemResponse        `json:"line_items"`
+	Payments        []PaymentResponse         `json:"payments"`
+	Charges         []ChargeResponse          `json:"charges"`
+	Settlement      *SettlementResponse       `json:"settlement"`
+	Refunds         []RefundResponse          `json:"refunds"`
+	RefundStatus    *string                   `json:"refund_status"`
+}
+
+type LineItemResponse struct {
+	Description string  `json:"description"`
+	Quantity    float64 `json:"quantity"`
+	UnitPrice   float64 `json:"unit_price"`
+	Amount      float64 `json:"amount"`
+}
+
+type PaymentResponse struct {
+	ID             uint      `json:"id"`
+	CreatedAt      time.Time `json:"created_at"`
+	Provider       string    `json:"provider"`
+	Kind           string    `json:"kind"`
+	Status         string    `json:"status"`
+	Amount         float64   `json:"amount"`
+	CapturedAmount float64   `json:"captured_amount"`
+}
+
+type RefundResponse struct {
+	ID          uint       `json:"id"`
+	CreatedAt   time.Time  `json:"created_at"`
+	PaymentID   uint       `json:"payment_id"`
+	Amount      float64    `json:"amount"`
+	Status      string     `json:"status"`
+	CompletedAt *time.Time `json:"completed_at"`
 }
 
 type MessagesResponse struct {
@@ -48,35 +143,109 @@ type MessagesResponse struct {
 	Message   string    `json:"message"`
 }
 
+type StatusChangeResponse struct {
+	ID         uint      `json:"id"`
+	CreatedAt  time.Time `json:"created_at"`
+	ActorID    *uint     `json:"actor_id"`
+	FromStatus string    `json:"from_status"`
+	ToStatus   string    `json:"to_status"`
+	Reason     string    `json:"reason"`
+}
+
+// RescheduleBookingRequest moves a booking to new dates. A zero VehicleID
+// keeps the booked vehicle.
+type RescheduleBookingRequest struct {
+	ID        uint      `param:"id" json:"-" validate:"required"`
+	VehicleID uint      `json:"vehicle_id"`
+	StartDate time.Time `json:"start_date" validate:"required"`
+	EndDate   time.Time `json:"end_date" validate:"required"`
+	Reason    string    `json:"reason"`
+}
+
+type ExtendBookingRequest struct {
+	ID      uint      `param:"id" json:"-" validate:"required"`
+	EndDate time.Time `json:"end_date" validate:"required"`
+	Message string    `json:"message"`
+}
+
+type ReviewExtensionRequest struct {
+	ID          uint   `param:"id" json:"-" validate:"required"`
+	ExtensionID uint   `param:"extension_id" json:"-" validate:"required"`
+	Approved    *bool  `json:"approved" validate:"required"`
+	Message     string `json:"message"`
+}
+
+type ExtensionResponse struct {
+	ID               uint      `json:"id"`
+	CreatedAt        time.Time `json:"created_at"`
+	Status           string    `json:"status"`
+	RequestedByID    uint      `json:"requested_by_id"`
+	ReviewedByID     *uint     `json:"reviewed_by_id"`
+	PreviousEndDate  time.Time `json:"previous_end_date"`
+	RequestedEndDate time.Time `json:"requested_end_date"`
+	ExtraCost        float64   `json:"extra_cost"`
+}
+
+type ChargeBookingRequest struct {
+	ID          uint    `param:"id" json:"-" validate:"required"`
+	Kind        string  `json:"kind" validate:"required,charge_kind"`
+	Description string  `json:"description" validate:"required"`
+	Amount      float64 `json:"amount" validate:"required,gt=0"`
+}
+
+type ChargeResponse struct {
+	ID          uint      `json:"id"`
+	CreatedAt   time.Time `json:"created_at"`
+	Kind        string    `json:"kind"`
+	Description string    `json:"description"`
+	Amount      float64   `json:"amount"`
+	PostedByID  uint      `json:"posted_by_id"`
+}
+
+type SettlementResponse struct {
+	HeldAmount         float64   `json:"held_amount"`
+	ChargedAmount      float64   `json:"charged_amount"`
+	CapturedAmount     float64   `json:"captured_amount"`
+	ReleasedAmount     float64   `json:"released_amount"`
+	ExtraChargedAmount float64   `json:"extra_charged_amount"`
+	SettledAt          time.Time `json:"settled_at"`
+}
+
 type CancelBookingRequest struct {
-	ID     uint `json:"id" validate:"required"`
-	UserID uint `json:"user_id" validate:"required"`
+	ID     uint   `json:"id" validate:"required"`
+	Reason string `json:"reason"`
 }
 
 type ConfirmBookingRequest struct {
-	ID     uint `json:"id" validate:"required"`
-	UserID uint `json:"user_id" validate:"required"`
+	ID     uint   `json:"id" validate:"required"`
+	Reason string `json:"reason"`
+}
+
+type CheckOutBookingRequest struct {
+	ID        uint   `json:"id" validate:"required"`
+	Odometer  *int   `json:"odometer" validate:"required,min=0"`
+	FuelLevel *int   `json:"fuel_level" validate:"required,min=0,max=100"`
+	Reason    string `json:"reason"`
 }
 
 type FinishBookingRequest struct {
-	ID     uint `json:"id" validate:"required"`
-	UserID uint `json:"user_id" validate:"required"`
+	ID        uint   `json:"id" validate:"required"`
+	Odometer  *int   `json:"odometer" validate:"required,min=0"`
+	FuelLevel *int   `json:"fuel_level" validate:"required,min=0,max=100"`
+	Reason    string `json:"reason"`
 }
 
 type AddFeedbackBookingRequest struct {
 	ID       uint   `json:"id" validate:"required"`
-	UserID   uint   `json:"user_id" validate:"required"`
 	Feedback string `json:"feedback" validate:"required"`
 }
 
 type RateBookingRequest struct {
 	ID     uint `json:"id" validate:"required"`
-	UserID uint `json:"user_id" validate:"required"`
 	Rating int  `json:"rating" validate:"required"`
 }
 
 type AddMessageToBookingRequest struct {
 	ID      uint   `json:"id" validate:"required"`
-	UserID  uint   `json:"user_id" validate:"required"`
 	Message string `json:"message" validate:"required"`
 }
@@ -0,0 +1,49 @@
+package requests
+
+const (
+	defaultPageSize = 20
+)
+
+// Pagination is embedded in list requests to read page and page_size from the
+// query string.
+type Pagination struct {
+	Page     int `query:"page" validate:"omitempty,min=1"`
+	PageSize int `query:"page_size" validate:"omitempty,min=1,max=100"`
+}
+
+func (p Pagination) CurrentPage() int {
+	if p.Page < 1 {
+		return 1
+	}
+
+	return p.Page
+}
+
+func (p Pagination) Limit() int {
+	if p.PageSize < 1 {
+		return defaultPageSize
+	}
+
+	return p.PageSize
+}
+
+func (p Pagination) Offset() int {
+	return (p.CurrentPage() - 1) * p.Limit()
+}
+
+// ListResponse is the envelope shared by every paginated listing.
+type ListResponse[T any] struct {
+	Items    []T   `json:"items"`
+	Total    int64 `json:"total"`
+	Page     int   `json:"page"`
+	PageSize int   `json:"page_size"`
+}
+
+func NewListResponse[T any](items []T, total int64, pagination Pagination) ListResponse[T] {
+	return ListResponse[T]{
+		Items:    items,
+		Total:    total,
+		Page:     pagination.CurrentPage(),
+		PageSize: pagination.Limit(),
+	}
+}
@@ -0,0 +1,44 @@
+package requests
+
+import "time"
+
+type CreatePromotionRequest struct {
+	Code           string    `json:"code" validate:"required,max=64"`
+	Description    string    `json:"description"`
+	DiscountType   string    `json:"discount_type" validate:"required,discount_type"`
+	DiscountValue  float64   `json:"discount_value" validate:"required,gt=0"`
+	ValidFrom      time.Time `json:"valid_from" validate:"required"`
+	ValidUntil     time.Time `json:"valid_until" validate:"required"`
+	VehicleTypes   []string  `json:"vehicle_types" validate:"dive,vehicle_type"`
+	MaxUses        int       `json:"max_uses" validate:"min=0"`
+	MaxUsesPerUser int       `json:"max_uses_per_user" validate:"min=0"`
+}
+
+type UpdatePromotionRequest struct {
+	ID             uint      `param:"id" json:"-" validate:"required"`
+	Code           string    `json:"code" validate:"required,max=64"`
+	Description    string    `json:"description"`
+	DiscountType   string    `json:"discount_type" validate:"required,discount_type"`
+	DiscountValue  float64   `json:"discount_value" validate:"required,gt=0"`
+	ValidFrom      time.Time `json:"valid_from" validate:"required"`
+	ValidUntil     time.Time `json:"valid_until" validate:"required"`
+	VehicleTypes   []string  `json:"vehicle_types" validate:"dive,vehicle_type"`
+	MaxUses        int       `json:"max_uses" validate:"min=0"`
+	MaxUsesPerUser int       `json:"max_uses_per_user" validate:"min=0"`
+}
+
+type PromotionResponse struct {
+	ID             uint      `json:"id"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+	Code           string    `json:"code"`
+	Description    string    `json:"description"`
+	DiscountType   string    `json:"discount_type"`
+	DiscountValue  float64   `json:"discount_value"`
+	ValidFrom      time.Time `json:"valid_from"`
+	ValidUntil     time.Time `json:"valid_until"`
+	VehicleTypes   []string  `json:"vehicle_types"`
+	MaxUses        int       `json:"max_uses"`
+	MaxUsesPerUser int       `json:"max_uses_per_user"`
+	UsesCount      int       `json:"uses_count"`
+}
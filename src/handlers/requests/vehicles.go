@@ -0,0 +1,87 @@
+package requests
+
+import "time"
+
+// RatePlan lists the optional rates of a vehicle. Zero rates are not offered.
+type RatePlan struct {
+	DailyCap          float64 `json:"daily_cap" validate:"min=0"`
+	WeeklyRate        float64 `json:"weekly_rate" validate:"min=0"`
+	WeekendHourlyFare float64 `json:"weekend_hourly_fare" validate:"min=0"`
+	MinimumHours      int     `json:"minimum_hours" validate:"min=0"`
+}
+
+type CreateVehicleRequest struct {
+	BrandModel       string  `json:"brand_model" validate:"required"`
+	Brand            string  `json:"brand" validate:"required"`
+	TransmissionType string  `json:"transmission_type" validate:"required,vehicle_transmission"`
+	Year             int     `json:"year" validate:"required,min=1950"`
+	Type             string  `json:"type" validate:"required,vehicle_type"`
+	Branch           string  `json:"branch" validate:"required"`
+	HourlyFare       float64 `json:"hourly_fare" validate:"required,gt=0"`
+	RatePlan
+}
+
+type UpdateVehicleRequest struct {
+	ID               uint    `param:"id" json:"-" validate:"required"`
+	BrandModel       string  `json:"brand_model" validate:"required"`
+	Brand            string  `json:"brand" validate:"required"`
+	TransmissionType string  `json:"transmission_type" validate:"required,vehicle_transmission"`
+	Year             int     `json:"year" validate:"required,min=1950"`
+	Type             string  `json:"type" validate:"required,vehicle_type"`
+	Branch           string  `json:"branch" validate:"required"`
+	HourlyFare       float64 `json:"hourly_fare" validate:"required,gt=0"`
+	RatePlan
+}
+
+type VehicleResponse struct {
+	ID               uint       `json:"id"`
+	CreatedAt        time.Time  `json:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at"`
+	DeletedAt        *time.Time `json:"deleted_at"`
+	Status           string     `json:"status"`
+	BrandModel       string     `json:"brand_model"`
+	Brand            string     `json:"brand"`
+	TransmissionType string     `json:"transmission_type"`
+	Year             int        `json:"year"`
+	Type             string     `json:"type"`
+	Branch           string     `json:"branch"`
+	HourlyFare       float64    `json:"hourly_fare"`
+	RatePlan
+}
+
+type ChangeVehicleStatusRequest struct {
+	ID     uint   `param:"id" json:"-" validate:"required"`
+	Status string `json:"status" validate:"required,vehicle_status"`
+	Reason string `json:"reason" validate:"required"`
+}
+
+type VehicleStatusChangeResponse struct {
+	ID         uint      `json:"id"`
+	CreatedAt  time.Time `json:"created_at"`
+	ActorID    uint      `json:"actor_id"`
+	FromStatus string    `json:"from_status"`
+	ToStatus   string    `json:"to_status"`
+	Reason     string    `json:"reason"`
+}
+
+type CreateMaintenanceWindowRequest struct {
+	VehicleID uint      `param:"id" json:"-" validate:"required"`
+	StartDate time.Time `json:"start_date" validate:"required"`
+	EndDate   time.Time `json:"end_date" validate:"required"`
+	Reason    string    `json:"reason" validate:"required"`
+}
+
+type MaintenanceWindowResponse struct {
+	ID          uint      `json:"id"`
+	CreatedAt   time.Time `json:"created_at"`
+	VehicleID   uint      `json:"vehicle_id"`
+	StartDate   time.Time `json:"start_date"`
+	EndDate     time.Time `json:"end_date"`
+	Reason      string    `json:"reason"`
+	CreatedByID uint      `json:"created_by_id"`
+}
+
+type ScheduleMaintenanceResponse struct {
+	MaintenanceWindow   MaintenanceWindowResponse `json:"maintenance_window"`
+	ConflictingBookings []BookingResponse         `json:"conflicting_bookings"`
+}
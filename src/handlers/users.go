@@ -3,7 +3,9 @@ package handlers
 import (
 	"errors"
 	"net/http"
+	"strconv"
 
+	"backend/src/auth"
 	"backend/src/commons"
 	"backend/src/domain"
 	"backend/src/handlers/requests"
@@ -13,22 +15,32 @@ import (
 
 type UsersService interface {
 	RegisterUser(context echo.Context, request requests.RegisterUserRequest) (*domain.User, error)
-	LoginUser(context echo.Context, request requests.LoginUserRequest) (*domain.User, error)
+	LoginUser(context echo.Context, request requests.LoginUserRequest) (*domain.User, *auth.TokenPair, error)
+	RefreshToken(context echo.Context, request requests.RefreshTokenRequest) (*domain.User, *auth.TokenPair, error)
+	Logout(context echo.Context, user domain.User, session domain.Session) error
+	GetSessions(context echo.Context, user domain.User) ([]domain.Session, error)
+	RevokeSession(context echo.Context, user domain.User, sessionID uint) error
 }
 
 type UsersHandler struct {
-	service UsersService
+	service      UsersService
+	authenticate echo.MiddlewareFunc
 }
 
-func NewUsersHandler(service UsersService) *UsersHandler {
+func NewUsersHandler(service UsersService, authenticate echo.MiddlewareFunc) *UsersHandler {
 	return &UsersHandler{
-		service: service,
+		service:      service,
+		authenticate: authenticate,
 	}
 }
 
 func (u *UsersHandler) AddRoutes(router *echo.Router) {
 	router.Add(echo.POST, "/users", u.RegisterUser)
 	router.Add(echo.POST, "/users/login", u.LoginUser)
+	router.Add(echo.POST, "/users/token/refresh", u.RefreshToken)
+	router.Add(echo.POST, "/users/logout", u.authenticate(u.Logout))
+	router.Add(echo.GET, "/users/sessions", u.authenticate(u.GetSessions))
+	router.Add(echo.DELETE, "/users/sessions/:id", u.authenticate(u.RevokeSession))
 }
 
 func (u *UsersHandler) RegisterUser(c echo.Context) error {
@@ -78,7 +90,7 @@ func (u *UsersHandler) LoginUser(c echo.Context) error {
 		})
 	}
 
-	user, err := u.service.LoginUser(c, *r)
+	user, tokens, err := u.service.LoginUser(c, *r)
 	if err != nil {
 		if errors.Is(err, commons.ErrUserNotFound) {
 			return c.JSON(http.StatusNotFound, echo.Map{
@@ -98,7 +110,93 @@ func (u *UsersHandler) LoginUser(c echo.Context) error {
 		})
 	}
 
-	return c.JSON(http.StatusOK, mapUserToResponse(*user))
+	return c.JSON(http.StatusOK, mapLoginToResponse(*user, *tokens))
+}
+
+func (u *UsersHandler) RefreshToken(c echo.Context) error {
+	r := new(requests.RefreshTokenRequest)
+	if err := c.Bind(r); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"layer": "RefreshTokenError Handler",
+			"error": err.Error(),
+		})
+	}
+	if err := c.Validate(r); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"layer": "RefreshTokenError Handler",
+			"error": err.Error(),
+		})
+	}
+
+	user, tokens, err := u.service.RefreshToken(c, *r)
+	if err != nil {
+		if errors.Is(err, commons.ErrInvalidRefreshToken) || errors.Is(err, commons.ErrRefreshTokenReused) {
+			return c.JSON(http.StatusUnauthorized, echo.Map{
+				"layer": "RefreshTokenError Handler",
+				"error": err.Error(),
+			})
+		}
+		return c.JSON(http.StatusInternalServerError, echo.Map{
+			"layer": "RefreshTokenError Handler",
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, mapLoginToResponse(*user, *tokens))
+}
+
+func (u *UsersHandler) Logout(c echo.Context) error {
+	if err := u.service.Logout(c, *auth.Principal(c), *auth.Session(c)); err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{
+			"layer": "LogoutError Handler",
+			"error": err.Error(),
+		})
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (u *UsersHandler) GetSessions(c echo.Context) error {
+	sessions, err := u.service.GetSessions(c, *auth.Principal(c))
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{
+			"layer": "GetSessionsError Handler",
+			"error": err.Error(),
+		})
+	}
+
+	current := auth.Session(c)
+	response := make([]*requests.SessionResponse, 0)
+	for _, session := range sessions {
+		response = append(response, mapSessionToResponse(session, current.ID))
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
+
+func (u *UsersHandler) RevokeSession(c echo.Context) error {
+	sessionID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil || sessionID == 0 {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"layer": "RevokeSessionError Handler",
+			"error": "invalid session id",
+		})
+	}
+
+	if err := u.service.RevokeSession(c, *auth.Principal(c), uint(sessionID)); err != nil {
+		if errors.Is(err, commons.ErrSessionNotFound) {
+			return c.JSON(http.StatusNotFound, echo.Map{
+				"layer": "RevokeSessionError Handler",
+				"error": err.Error(),
+			})
+		}
+		return c.JSON(http.StatusInternalServerError, echo.Map{
+			"layer": "RevokeSessionError Handler",
+			"error": err.Error(),
+		})
+	}
+
+	return c.NoContent(http.StatusNoContent)
 }
 
 func mapUserToResponse(user domain.User) *requests.UserResponse {
@@ -110,3 +208,28 @@ func mapUserToResponse(user domain.User) *requests.UserResponse {
 		Type:  user.Type,
 	}
 }
+
+func mapLoginToResponse(user domain.User, tokens auth.TokenPair) *requests.LoginUserResponse {
+	return &requests.LoginUserResponse{
+		UserResponse: *mapUserToResponse(user),
+		TokenResponse: requests.TokenResponse{
+			AccessToken:      tokens.AccessToken,
+			TokenType:        "Bearer",
+			ExpiresAt:        tokens.AccessTokenExpiresAt,
+			RefreshToken:     tokens.RefreshToken,
+			RefreshExpiresAt: tokens.RefreshTokenExpiresAt,
+		},
+	}
+}
+
+func mapSessionToResponse(session domain.Session, currentSessionID uint) *requests.SessionResponse {
+	return &requests.SessionResponse{
+		ID:         session.ID,
+		CreatedAt:  session.CreatedAt,
+		LastUsedAt: session.LastUsedAt,
+		ExpiresAt:  session.ExpiresAt,
+		UserAgent:  session.UserAgent,
+		IPAddress:  session.IPAddress,
+		Current:    session.ID == currentSessionID,
+	}
+}
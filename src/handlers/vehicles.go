@@ -0,0 +1,433 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"backend/src/auth"
+	"backend/src/commons"
+	"backend/src/domain"
+	"backend/src/handlers/requests"
+	"backend/src/policy"
+
+	"github.com/labstack/echo/v4"
+)
+
+type VehiclesService interface {
+	GetVehicles(includeRetired bool) ([]domain.Vehicle, error)
+	GetVehicleByID(vehicleID uint) (*domain.Vehicle, error)
+	CreateVehicle(request requests.CreateVehicleRequest) (*domain.Vehicle, error)
+	UpdateVehicle(request requests.UpdateVehicleRequest) (*domain.Vehicle, error)
+	ChangeVehicleStatus(user domain.User, request requests.ChangeVehicleStatusRequest) (*domain.Vehicle, error)
+	RetireVehicle(user domain.User, vehicleID uint) error
+	GetVehicleStatusHistory(vehicleID uint) ([]domain.VehicleStatusChange, error)
+	GetMaintenanceWindows(vehicleID uint) ([]domain.MaintenanceWindow, error)
+	ScheduleMaintenance(user domain.User, request requests.CreateMaintenanceWindowRequest) (*domain.MaintenanceWindow, []domain.Booking, error)
+	DeleteMaintenanceWindow(vehicleID uint, windowID uint) error
+}
+
+type VehiclesHandler struct {
+	service      VehiclesService
+	authenticate echo.MiddlewareFunc
+}
+
+func NewVehiclesHandler(service VehiclesService, authenticate echo.MiddlewareFunc) *VehiclesHandler {
+	return &VehiclesHandler{
+		service:      service,
+		authenticate: authenticate,
+	}
+}
+
+func (h *VehiclesHandler) AddRoutes(router *echo.Router) {
+	read := func(next echo.HandlerFunc) echo.HandlerFunc {
+		return h.authenticate(policy.Require(policy.VehiclesRead)(next))
+	}
+	manage := func(next echo.HandlerFunc) echo.HandlerFunc {
+		return h.authenticate(policy.Require(policy.VehiclesManage)(next))
+	}
+
+	router.Add(echo.GET, "/vehicles", read(h.getVehicles))
+	router.Add(echo.GET, "/vehicles/:id", read(h.getVehicle))
+	router.Add(echo.GET, "/vehicles/:id/status-history", read(h.getVehicleStatusHistory))
+	router.Add(echo.POST, "/vehicles", manage(h.createVehicle))
+	router.Add(echo.PUT, "/vehicles/:id", manage(h.updateVehicle))
+	router.Add(echo.PATCH, "/vehicles/:id/status", manage(h.changeVehicleStatus))
+	router.Add(echo.DELETE, "/vehicles/:id", manage(h.retireVehicle))
+	router.Add(echo.GET, "/vehicles/:id/maintenance-windows", read(h.getMaintenanceWindows))
+	router.Add(echo.POST, "/vehicles/:id/maintenance-windows", manage(h.scheduleMaintenance))
+	router.Add(echo.DELETE, "/vehicles/:id/maintenance-windows/:window_id", manage(h.deleteMaintenanceWindow))
+}
+
+func (h *VehiclesHandler) getVehicles(c echo.Context) error {
+	includeRetired := c.QueryParam("include_retired") == "true"
+
+	vehicles, err := h.service.GetVehicles(includeRetired)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{
+			"message": err.Error(),
+		})
+	}
+
+	response := make([]*requests.VehicleResponse, 0)
+	for _, vehicle := range vehicles {
+		response = append(response, mapVehicleToAdminResponse(vehicle))
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
+
+func (h *VehiclesHandler) getVehicle(c echo.Context) error {
+	vehicleID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil || vehicleID == 0 {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"message": "invalid vehicle id",
+		})
+	}
+
+	vehicle, err := h.service.GetVehicleByID(uint(vehicleID))
+	if err != nil {
+		if errors.Is(err, commons.ErrVehicleNotFound) {
+			return c.JSON(http.StatusNotFound, echo.Map{
+				"message": err.Error(),
+			})
+		}
+
+		return c.JSON(http.StatusInternalServerError, echo.Map{
+			"message": err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, mapVehicleToAdminResponse(*vehicle))
+}
+
+func (h *VehiclesHandler) createVehicle(c echo.Context) error {
+	r := new(requests.CreateVehicleRequest)
+	if err := c.Bind(r); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"message": err.Error(),
+		})
+	}
+
+	if err := c.Validate(r); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"message": err.Error(),
+		})
+	}
+
+	if r.Year > time.Now().Year()+1 {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"message": "invalid vehicle year",
+		})
+	}
+
+	vehicle, err := h.service.CreateVehicle(*r)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{
+			"message": err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusCreated, mapVehicleToAdminResponse(*vehicle))
+}
+
+func (h *VehiclesHandler) updateVehicle(c echo.Context) error {
+	r := new(requests.UpdateVehicleRequest)
+	if err := c.Bind(r); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"message": err.Error(),
+		})
+	}
+
+	if err := c.Validate(r); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"message": err.Error(),
+		})
+	}
+
+	if r.Year > time.Now().Year()+1 {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"message": "invalid vehicle year",
+		})
+	}
+
+	vehicle, err := h.service.UpdateVehicle(*r)
+	if err != nil {
+		if errors.Is(err, commons.ErrVehicleNotFound) {
+			return c.JSON(http.StatusNotFound, echo.Map{
+				"message": err.Error(),
+			})
+		}
+
+		return c.JSON(http.StatusInternalServerError, echo.Map{
+			"message": err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, mapVehicleToAdminResponse(*vehicle))
+}
+
+func (h *VehiclesHandler) retireVehicle(c echo.Context) error {
+	vehicleID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil || vehicleID == 0 {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"message": "invalid vehicle id",
+		})
+	}
+
+	if err := h.service.RetireVehicle(*auth.Principal(c), uint(vehicleID)); err != nil {
+		if errors.Is(err, commons.ErrVehicleNotFound) {
+			return c.JSON(http.StatusNotFound, echo.Map{
+				"message": err.Error(),
+			})
+		}
+
+		if errors.Is(err, commons.ErrVehicleHasActiveBookings) {
+			return c.JSON(http.StatusConflict, echo.Map{
+				"message": err.Error(),
+			})
+		}
+
+		if errors.Is(err, commons.ErrInvalidVehicleStatus) {
+			return c.JSON(http.StatusConflict, echo.Map{
+				"message": err.Error(),
+			})
+		}
+
+		return c.JSON(http.StatusInternalServerError, echo.Map{
+			"message": err.Error(),
+		})
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (h *VehiclesHandler) changeVehicleStatus(c echo.Context) error {
+	r := new(requests.ChangeVehicleStatusRequest)
+	if err := c.Bind(r); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"message": err.Error(),
+		})
+	}
+
+	if err := c.Validate(r); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"message": err.Error(),
+		})
+	}
+
+	vehicle, err := h.service.ChangeVehicleStatus(*auth.Principal(c), *r)
+	if err != nil {
+		if errors.Is(err, commons.ErrVehicleNotFound) {
+			return c.JSON(http.StatusNotFound, echo.Map{
+				"message": err.Error(),
+			})
+		}
+
+		if errors.Is(err, commons.ErrInvalidVehicleStatus) {
+			return c.JSON(http.StatusConflict, echo.Map{
+				"message": err.Error(),
+			})
+		}
+
+		if errors.Is(err, commons.ErrVehicleHasActiveBookings) {
+			return c.JSON(http.StatusConflict, echo.Map{
+				"message": err.Error(),
+			})
+		}
+
+		return c.JSON(http.StatusInternalServerError, echo.Map{
+			"message": err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, mapVehicleToAdminResponse(*vehicle))
+}
+
+func (h *VehiclesHandler) getVehicleStatusHistory(c echo.Context) error {
+	vehicleID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil || vehicleID == 0 {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"message": "invalid vehicle id",
+		})
+	}
+
+	changes, err := h.service.GetVehicleStatusHistory(uint(vehicleID))
+	if err != nil {
+		if errors.Is(err, commons.ErrVehicleNotFound) {
+			return c.JSON(http.StatusNotFound, echo.Map{
+				"message": err.Error(),
+			})
+		}
+
+		return c.JSON(http.StatusInternalServerError, echo.Map{
+			"message": err.Error(),
+		})
+	}
+
+	response := make([]*requests.VehicleStatusChangeResponse, 0)
+	for _, change := range changes {
+		response = append(response, mapVehicleStatusChangeToResponse(change))
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
+
+func (h *VehiclesHandler) getMaintenanceWindows(c echo.Context) error {
+	vehicleID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil || vehicleID == 0 {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"message": "invalid vehicle id",
+		})
+	}
+
+	windows, err := h.service.GetMaintenanceWindows(uint(vehicleID))
+	if err != nil {
+		if errors.Is(err, commons.ErrVehicleNotFound) {
+			return c.JSON(http.StatusNotFound, echo.Map{
+				"message": err.Error(),
+			})
+		}
+
+		return c.JSON(http.StatusInternalServerError, echo.Map{
+			"message": err.Error(),
+		})
+	}
+
+	response := make([]*requests.MaintenanceWindowResponse, 0)
+	for _, window := range windows {
+		response = append(response, mapMaintenanceWindowToResponse(window))
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
+
+func (h *VehiclesHandler) scheduleMaintenance(c echo.Context) error {
+	r := new(requests.CreateMaintenanceWindowRequest)
+	if err := c.Bind(r); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"message": err.Error(),
+		})
+	}
+
+	if err := c.Validate(r); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"message": err.Error(),
+		})
+	}
+
+	if !r.EndDate.After(r.StartDate) {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"message": "start date must be before end date",
+		})
+	}
+
+	window, conflicts, err := h.service.ScheduleMaintenance(*auth.Principal(c), *r)
+	if err != nil {
+		if errors.Is(err, commons.ErrVehicleNotFound) {
+			return c.JSON(http.StatusNotFound, echo.Map{
+				"message": err.Error(),
+			})
+		}
+
+		return c.JSON(http.StatusInternalServerError, echo.Map{
+			"message": err.Error(),
+		})
+	}
+
+	conflictingBookings := make([]requests.BookingResponse, 0)
+	for _, booking := range conflicts {
+		conflictingBookings = append(conflictingBookings, *mapBookingToResponse(booking))
+	}
+
+	return c.JSON(http.StatusCreated, requests.ScheduleMaintenanceResponse{
+		MaintenanceWindow:   *mapMaintenanceWindowToResponse(*window),
+		ConflictingBookings: conflictingBookings,
+	})
+}
+
+func (h *VehiclesHandler) deleteMaintenanceWindow(c echo.Context) error {
+	vehicleID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil || vehicleID == 0 {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"message": "invalid vehicle id",
+		})
+	}
+
+	windowID, err := strconv.ParseUint(c.Param("window_id"), 10, 32)
+	if err != nil || windowID == 0 {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"message": "invalid maintenance window id",
+		})
+	}
+
+	if err := h.service.DeleteMaintenanceWindow(uint(vehicleID), uint(windowID)); err != nil {
+		if errors.Is(err, commons.ErrMaintenanceWindowNotFound) {
+			return c.JSON(http.StatusNotFound, echo.Map{
+				"message": err.Error(),
+			})
+		}
+
+		return c.JSON(http.StatusInternalServerError, echo.Map{
+			"message": err.Error(),
+		})
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+func mapVehicleToAdminResponse(vehicle domain.Vehicle) *requests.VehicleResponse {
+	var deletedAt *time.Time
+	if vehicle.DeletedAt.Valid {
+		deletedAt = &vehicle.DeletedAt.Time
+	}
+
+	return &requests.VehicleResponse{
+		ID:               vehicle.ID,
+		CreatedAt:        vehicle.CreatedAt,
+		UpdatedAt:        vehicle.UpdatedAt,
+		DeletedAt:        deletedAt,
+		Status:           vehicle.Status,
+		BrandModel:       vehicle.BrandModel,
+		Brand:            vehicle.Brand,
+		TransmissionType: vehicle.TransmissionType,
+		Year:             vehicle.Year,
+		Type:             vehicle.Type,
+		Branch:           vehicle.Branch,
+		HourlyFare:       vehicle.HourlyFare,
+		RatePlan:         *mapRatePlanToResponse(vehicle.RatePlan),
+	}
+}
+
+func mapRatePlanToResponse(plan domain.RatePlan) *requests.RatePlan {
+	return &requests.RatePlan{
+		DailyCap:          plan.DailyCap,
+		WeeklyRate:        plan.WeeklyRate,
+		WeekendHourlyFare: plan.WeekendHourlyFare,
+		MinimumHours:      plan.MinimumHours,
+	}
+}
+
+func mapVehicleStatusChangeToResponse(change domain.VehicleStatusChange) *requests.VehicleStatusChangeResponse {
+	return &requests.VehicleStatusChangeResponse{
+		ID:         change.ID,
+		CreatedAt:  change.CreatedAt,
+		ActorID:    change.ActorID,
+		FromStatus: change.FromStatus,
+		ToStatus:   change.ToStatus,
+		Reason:     change.Reason,
+	}
+}
+
+func mapMaintenanceWindowToResponse(window domain.MaintenanceWindow) *requests.MaintenanceWindowResponse {
+	return &requests.MaintenanceWindowResponse{
+		ID:          window.ID,
+		CreatedAt:   window.CreatedAt,
+		VehicleID:   window.VehicleID,
+		StartDate:   window.StartDate,
+		EndDate:     window.EndDate,
+		Reason:      window.Reason,
+		CreatedByID: window.CreatedByID,
+	}
+}
@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"backend/src/commons"
+	"backend/src/payments"
+
+	"github.com/labstack/echo/v4"
+)
+
+type WebhooksService interface {
+	HandlePaymentEvent(provider string, event payments.Event, now time.Time) (bool, error)
+}
+
+// WebhooksHandler receives the events payment gateways send. Gateways do not
+// log in; the signature on every event is checked instead.
+type WebhooksHandler struct {
+	service  WebhooksService
+	webhooks *payments.Webhooks
+}
+
+func NewWebhooksHandler(service WebhooksService, webhooks *payments.Webhooks) *WebhooksHandler {
+	return &WebhooksHandler{
+		service:  service,
+		webhooks: webhooks,
+	}
+}
+
+func (h *WebhooksHandler) AddRoutes(router *echo.Router) {
+	router.Add(echo.POST, "/webhooks/payments/:provider", h.handlePaymentEvent)
+}
+
+func (h *WebhooksHandler) handlePaymentEvent(c echo.Context) error {
+	// The signature covers the exact bytes sent, so the body is read as is
+	body, err := io.ReadAll(c.Request().Body)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"message": err.Error(),
+		})
+	}
+
+	now := time.Now()
+	provider := c.Param("provider")
+	event, err := h.webhooks.Verify(
+		provider,
+		c.Request().Header.Get(payments.TimestampHeader),
+		c.Request().Header.Get(payments.SignatureHeader),
+		body,
+		now,
+	)
+	if err != nil {
+		if errors.Is(err, commons.ErrUnknownPaymentProvider) {
+			return c.JSON(http.StatusNotFound, echo.Map{
+				"message": err.Error(),
+			})
+		}
+
+		if errors.Is(err, commons.ErrInvalidWebhookSignature) {
+			return c.JSON(http.StatusUnauthorized, echo.Map{
+				"message": err.Error(),
+			})
+		}
+
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"message": err.Error(),
+		})
+	}
+
+	processed, err := h.service.HandlePaymentEvent(provider, *event, now)
+	if err != nil {
+		if errors.Is(err, commons.ErrPaymentNotFound) || errors.Is(err, commons.ErrRefundNotFound) {
+			return c.JSON(http.StatusNotFound, echo.Map{
+				"message": err.Error(),
+			})
+		}
+
+		return c.JSON(http.StatusInternalServerError, echo.Map{
+			"message": err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{
+		"id":        event.ID,
+		"duplicate": !processed,
+	})
+}
@@ -0,0 +1,18 @@
+package notifications
+
+import (
+	"github.com/labstack/gommon/log"
+)
+
+// LogNotifier delivers notifications to the server log. It is used until a
+// real delivery channel (email, chat) is configured.
+type LogNotifier struct{}
+
+func NewLogNotifier() *LogNotifier {
+	return &LogNotifier{}
+}
+
+func (n *LogNotifier) NotifyAdmins(subject string, message string) error {
+	log.Warnf("[admin notification] %s: %s", subject, message)
+	return nil
+}
@@ -0,0 +1,131 @@
+package payments
+
+import (
+	"fmt"
+	"sync"
+
+	"backend/src/commons"
+)
+
+// FakeProvider is an in-memory gateway for development. It keeps track of
+// every payment made through it and rejects operations a real gateway would
+// reject, but payments are forgotten when the server restarts. Refunds stay
+// pending until their status is first checked.
+type FakeProvider struct {
+	// DeclineAbove declines authorizations over this amount. Zero accepts
+	// any amount.
+	DeclineAbove float64
+
+	mu           sync.Mutex
+	nextID       int
+	transactions map[string]*fakeTransaction
+	refunds      map[string]string
+}
+
+type fakeTransaction struct {
+	status     string
+	authorized float64
+	captured   float64
+	refunded   float64
+}
+
+func NewFakeProvider(declineAbove float64) *FakeProvider {
+	return &FakeProvider{
+		DeclineAbove: declineAbove,
+		transactions: make(map[string]*fakeTransaction),
+		refunds:      make(map[string]string),
+	}
+}
+
+func (f *FakeProvider) Name() string {
+	return "fake"
+}
+
+func (f *FakeProvider) Authorize(amount float64, reference string) (*Transaction, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if amount <= 0 || (f.DeclineAbove > 0 && amount > f.DeclineAbove) {
+		return nil, commons.ErrPaymentDeclined
+	}
+
+	f.nextID++
+	id := fmt.Sprintf("fake_%s_%d", reference, f.nextID)
+	f.transactions[id] = &fakeTransaction{
+		status:     commons.PaymentStatusAuthorized,
+		authorized: amount,
+	}
+
+	return &Transaction{ID: id, Status: commons.PaymentStatusAuthorized}, nil
+}
+
+func (f *FakeProvider) Capture(transactionID string, amount float64) (*Transaction, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	transaction, ok := f.transactions[transactionID]
+	if !ok {
+		return nil, commons.ErrPaymentNotFound
+	}
+
+	if transaction.status != commons.PaymentStatusAuthorized || amount <= 0 || amount > transaction.authorized {
+		return nil, commons.ErrInvalidPaymentOperation
+	}
+
+	transaction.status = commons.PaymentStatusCaptured
+	transaction.captured = amount
+
+	return &Transaction{ID: transactionID, Status: transaction.status}, nil
+}
+
+func (f *FakeProvider) Void(transactionID string) (*Transaction, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	transaction, ok := f.transactions[transactionID]
+	if !ok {
+		return nil, commons.ErrPaymentNotFound
+	}
+
+	if transaction.status != commons.PaymentStatusAuthorized {
+		return nil, commons.ErrInvalidPaymentOperation
+	}
+
+	transaction.status = commons.PaymentStatusVoided
+
+	return &Transaction{ID: transactionID, Status: transaction.status}, nil
+}
+
+func (f *FakeProvider) Refund(transactionID string, amount float64) (*Transaction, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	transaction, ok := f.transactions[transactionID]
+	if !ok {
+		return nil, commons.ErrPaymentNotFound
+	}
+
+	if transaction.status != commons.PaymentStatusCaptured || amount <= 0 || amount > transaction.captured-transaction.refunded {
+		return nil, commons.ErrInvalidPaymentOperation
+	}
+
+	transaction.refunded += amount
+	f.nextID++
+	id := fmt.Sprintf("%s_refund_%d", transactionID, f.nextID)
+	f.refunds[id] = commons.RefundStatusPending
+
+	return &Transaction{ID: id, Status: commons.RefundStatusPending}, nil
+}
+
+func (f *FakeProvider) RefundStatus(refundID string) (*Transaction, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, ok := f.refunds[refundID]; !ok {
+		return nil, commons.ErrPaymentNotFound
+	}
+
+	f.refunds[refundID] = commons.RefundStatusCompleted
+
+	return &Transaction{ID: refundID, Status: commons.RefundStatusCompleted}, nil
+}
@@ -0,0 +1,24 @@
+package payments
+
+// Transaction is the outcome of an operation on a payment gateway. ID is the
+// reference the gateway knows the payment by, and Status is one of the
+// commons payment statuses.
+type Transaction struct {
+	ID     string
+	Status string
+}
+
+// PaymentProvider moves money through a payment gateway. Money is first put on
+// hold with Authorize, then either taken with Capture or released with Void.
+// Captured money can be given back with Refund, which the gateway settles
+// later; RefundStatus tells how a refund is going. Amounts never go above
+// what was authorized or captured before.
+type PaymentProvider interface {
+	// Name identifies the provider on stored payments.
+	Name() string
+	Authorize(amount float64, reference string) (*Transaction, error)
+	Capture(transactionID string, amount float64) (*Transaction, error)
+	Void(transactionID string) (*Transaction, error)
+	Refund(transactionID string, amount float64) (*Transaction, error)
+	RefundStatus(refundID string) (*Transaction, error)
+}
@@ -0,0 +1,91 @@
+package payments
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"backend/src/commons"
+)
+
+const (
+	SignatureHeader = "X-Payments-Signature"
+	TimestampHeader = "X-Payments-Timestamp"
+
+	EventPaymentAuthorized = "payment.authorized"
+	EventPaymentCaptured   = "payment.captured"
+	EventPaymentVoided     = "payment.voided"
+	EventPaymentFailed     = "payment.failed"
+	EventRefundCompleted   = "refund.completed"
+	EventRefundFailed      = "refund.failed"
+)
+
+// Event is a notification from a payment gateway about a transaction made
+// through it. TransactionID is the reference the gateway returned when the
+// payment or refund was made, and Amount is only set on captures.
+type Event struct {
+	ID            string  `json:"id"`
+	Type          string  `json:"type"`
+	TransactionID string  `json:"transaction_id"`
+	Amount        float64 `json:"amount"`
+}
+
+// Webhooks checks that events really come from the gateway they claim to.
+// Every provider signs its events with its own secret.
+type Webhooks struct {
+	secrets   map[string]string
+	tolerance time.Duration
+}
+
+func NewWebhooks(secrets map[string]string, tolerance time.Duration) *Webhooks {
+	return &Webhooks{
+		secrets:   secrets,
+		tolerance: tolerance,
+	}
+}
+
+// Verify checks body was signed by provider no longer than the tolerance ago
+// and parses the event in it. Old signatures are rejected so captured requests
+// cannot be replayed.
+func (w *Webhooks) Verify(provider string, timestamp string, signature string, body []byte, now time.Time) (*Event, error) {
+	secret, ok := w.secrets[provider]
+	if !ok || secret == "" {
+		return nil, commons.ErrUnknownPaymentProvider
+	}
+
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return nil, commons.ErrInvalidWebhookSignature
+	}
+
+	signedAt := time.Unix(seconds, 0)
+	if now.Sub(signedAt) > w.tolerance || signedAt.Sub(now) > w.tolerance {
+		return nil, commons.ErrInvalidWebhookSignature
+	}
+
+	expected := Sign(secret, timestamp, body)
+	if !hmac.Equal([]byte(expected), []byte(signature)) {
+		return nil, commons.ErrInvalidWebhookSignature
+	}
+
+	var event Event
+	if err := json.Unmarshal(body, &event); err != nil || event.ID == "" || event.Type == "" || event.TransactionID == "" {
+		return nil, commons.ErrInvalidWebhookEvent
+	}
+
+	return &event, nil
+}
+
+// Sign is the hex encoded HMAC-SHA256 of the timestamp and the body, joined by
+// a dot, keyed with secret.
+func Sign(secret string, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
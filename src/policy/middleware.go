@@ -0,0 +1,35 @@
+package policy
+
+import (
+	"net/http"
+
+	"backend/src/auth"
+	"backend/src/commons"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Require rejects requests whose authenticated user lacks any of permissions.
+// It must run after auth.Middleware.
+func Require(permissions ...Permission) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			user := auth.Principal(c)
+			if user == nil {
+				return c.JSON(http.StatusUnauthorized, echo.Map{
+					"message": "missing access token",
+				})
+			}
+
+			for _, permission := range permissions {
+				if !Has(*user, permission) {
+					return c.JSON(http.StatusForbidden, echo.Map{
+						"message": commons.ErrForbidden.Error(),
+					})
+				}
+			}
+
+			return next(c)
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package policy
+
+import (
+	"backend/src/commons"
+	"backend/src/domain"
+)
+
+type Permission string
+
+const (
+	BookingsCreate             Permission = "bookings:create"
+	BookingsReadOwn            Permission = "bookings:read:own"
+	BookingsReadAny            Permission = "bookings:read:any"
+	BookingsCancelOwn          Permission = "bookings:cancel:own"
+	BookingsCancelAny          Permission = "bookings:cancel:any"
+	BookingsConfirmOwn         Permission = "bookings:confirm:own"
+	BookingsConfirmAny         Permission = "bookings:confirm:any"
+	BookingsRescheduleOwn      Permission = "bookings:reschedule:own"
+	BookingsRescheduleAny      Permission = "bookings:reschedule:any"
+	BookingsExtendOwn          Permission = "bookings:extend:own"
+	BookingsExtendAny          Permission = "bookings:extend:any"
+	BookingsReviewExtensionAny Permission = "bookings:review-extension:any"
+	BookingsFinishAny          Permission = "bookings:finish:any"
+	BookingsChargeAny          Permission = "bookings:charge:any"
+	BookingsPickUpAny          Permission = "bookings:pick-up:any"
+	BookingsFeedbackOwn        Permission = "bookings:feedback:own"
+	BookingsRateOwn            Permission = "bookings:rate:own"
+	BookingsMessageOwn         Permission = "bookings:message:own"
+	BookingsMessageAny         Permission = "bookings:message:any"
+	VehiclesRead               Permission = "vehicles:read"
+	VehiclesManage             Permission = "vehicles:manage"
+	PromotionsManage           Permission = "promotions:manage"
+)
+
+// Action pairs the permission needed to act on your own resources with the
+// one needed to act on anybody's. An empty permission means nobody gets that
+// kind of access.
+type Action struct {
+	Own Permission
+	Any Permission
+}
+
+var (
+	ReadBooking       = Action{Own: BookingsReadOwn, Any: BookingsReadAny}
+	CancelBooking     = Action{Own: BookingsCancelOwn, Any: BookingsCancelAny}
+	ConfirmBooking    = Action{Own: BookingsConfirmOwn, Any: BookingsConfirmAny}
+	RescheduleBooking = Action{Own: BookingsRescheduleOwn, Any: BookingsRescheduleAny}
+	ExtendBooking     = Action{Own: BookingsExtendOwn, Any: BookingsExtendAny}
+	PickUpBooking     = Action{Any: BookingsPickUpAny}
+	FinishBooking     = Action{Any: BookingsFinishAny}
+	FeedbackBooking   = Action{Own: BookingsFeedbackOwn}
+	RateBooking       = Action{Own: BookingsRateOwn}
+	MessageBooking    = Action{Own: BookingsMessageOwn, Any: BookingsMessageAny}
+)
+
+var rolePermissions = map[string][]Permission{
+	commons.UserTypeClient: {
+		BookingsCreate,
+		BookingsReadOwn,
+		BookingsCancelOwn,
+		BookingsConfirmOwn,
+		BookingsRescheduleOwn,
+		BookingsExtendOwn,
+		BookingsFeedbackOwn,
+		BookingsRateOwn,
+		BookingsMessageOwn,
+	},
+	commons.UserTypeAdmin: {
+		BookingsCreate,
+		BookingsReadAny,
+		BookingsCancelAny,
+		BookingsConfirmAny,
+		BookingsRescheduleAny,
+		BookingsExtendAny,
+		BookingsReviewExtensionAny,
+		BookingsPickUpAny,
+		BookingsFinishAny,
+		BookingsChargeAny,
+		BookingsMessageAny,
+		VehiclesRead,
+		VehiclesManage,
+		PromotionsManage,
+	},
+	commons.UserTypeFleetOperator: {
+		BookingsReadAny,
+		BookingsConfirmAny,
+		BookingsExtendAny,
+		BookingsReviewExtensionAny,
+		BookingsPickUpAny,
+		BookingsFinishAny,
+		BookingsChargeAny,
+		BookingsMessageAny,
+		VehiclesRead,
+		VehiclesManage,
+	},
+	commons.UserTypeSupportAgent: {
+		BookingsReadAny,
+		BookingsCancelAny,
+		BookingsRescheduleAny,
+		BookingsMessageAny,
+		VehiclesRead,
+	},
+}
+
+// Has reports whether the role of user grants permission.
+func Has(user domain.User, permission Permission) bool {
+	for _, granted := range rolePermissions[user.Type] {
+		if granted == permission {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Can reports whether user may perform action on a resource owned by ownerID.
+func Can(user domain.User, action Action, ownerID uint) bool {
+	if action.Any != "" && Has(user, action.Any) {
+		return true
+	}
+
+	return action.Own != "" && ownerID == user.ID && Has(user, action.Own)
+}
@@ -0,0 +1,227 @@
+package pricing
+
+import (
+	"math"
+	"slices"
+	"time"
+
+	"backend/src/domain"
+)
+
+const (
+	week = 7 * day
+	day  = 24 * time.Hour
+)
+
+// Plan is every rate a rental can be priced with. Zero rates other than
+// HourlyFare are not offered.
+type Plan struct {
+	HourlyFare        float64
+	WeekendHourlyFare float64
+	DailyCap          float64
+	WeeklyRate        float64
+	MinimumDuration   time.Duration
+}
+
+func VehiclePlan(vehicle domain.Vehicle) Plan {
+	return newPlan(vehicle.HourlyFare, vehicle.RatePlan)
+}
+
+// BookingPlan is the plan a booking was priced with when it was created.
+func BookingPlan(booking domain.Booking) Plan {
+	return newPlan(booking.HourlyFare, booking.RatePlan)
+}
+
+func newPlan(hourlyFare float64, rates domain.RatePlan) Plan {
+	return Plan{
+		HourlyFare:        hourlyFare,
+		WeekendHourlyFare: rates.WeekendHourlyFare,
+		DailyCap:          rates.DailyCap,
+		WeeklyRate:        rates.WeeklyRate,
+		MinimumDuration:   time.Duration(rates.MinimumHours) * time.Hour,
+	}
+}
+
+// Rates are the rates of the plan other than its hourly fare, as bookings and
+// vehicles keep them.
+func (p Plan) Rates() domain.RatePlan {
+	return domain.RatePlan{
+		DailyCap:          p.DailyCap,
+		WeeklyRate:        p.WeeklyRate,
+		WeekendHourlyFare: p.WeekendHourlyFare,
+		MinimumHours:      int(p.MinimumDuration / time.Hour),
+	}
+}
+
+// Rules are the rounding rules applied to every price.
+type Rules struct {
+	// Increment is the unit time is billed in. Partial increments are
+	// billed as whole ones.
+	Increment time.Duration
+	// Grace is how long past a whole increment is not billed at all.
+	Grace time.Duration
+	// TaxRate is charged on top of the rental, e.g. 0.19 for 19%.
+	TaxRate float64
+}
+
+type Line struct {
+	Description string
+	Quantity    float64
+	UnitPrice   float64
+	Amount      float64
+}
+
+// Breakdown is a priced rental. Lines add up to Subtotal, discounts included,
+// and Total is the Subtotal with taxes.
+type Breakdown struct {
+	Lines    []Line
+	Subtotal float64
+	Discount float64
+	Tax      float64
+	Total    float64
+}
+
+type Calculator struct {
+	rules Rules
+}
+
+func NewCalculator(rules Rules) *Calculator {
+	return &Calculator{
+		rules: rules,
+	}
+}
+
+// Price prices a rental between from and to. Whole weeks go at the weekly
+// rate, every day is capped at the daily cap and the hours left are billed at
+// the hourly fare of the day they fall on. Rentals shorter than the minimum
+// duration are billed as long as the minimum. Taxes are added last.
+func (c *Calculator) Price(plan Plan, from time.Time, to time.Time) Breakdown {
+	billed := c.billedDuration(to.Sub(from))
+	if billed < plan.MinimumDuration {
+		billed = plan.MinimumDuration
+	}
+
+	var breakdown Breakdown
+	start := from
+	if plan.WeeklyRate > 0 {
+		if weeks := int(billed / week); weeks > 0 {
+			breakdown.add("Weekly rate", float64(weeks), plan.WeeklyRate)
+			start = start.Add(time.Duration(weeks) * week)
+			billed -= time.Duration(weeks) * week
+		}
+	}
+
+	for billed > 0 {
+		length := min(billed, day)
+		weekdayHours, weekendHours := length.Hours(), 0.0
+		if plan.WeekendHourlyFare > 0 {
+			weekdayHours, weekendHours = c.splitWeekend(start, length)
+		}
+
+		cost := weekdayHours*plan.HourlyFare + weekendHours*plan.WeekendHourlyFare
+		if plan.DailyCap > 0 && cost > plan.DailyCap {
+			breakdown.add("Daily cap", 1, plan.DailyCap)
+		} else {
+			breakdown.add("Hourly rate", weekdayHours, plan.HourlyFare)
+			breakdown.add("Weekend hourly rate", weekendHours, plan.WeekendHourlyFare)
+		}
+
+		start = start.Add(length)
+		billed -= length
+	}
+
+	c.addTax(&breakdown)
+
+	return breakdown
+}
+
+// Discount takes amount off breakdown as a line of its own and recomputes the
+// taxes. A discount never takes the subtotal below zero.
+func (c *Calculator) Discount(breakdown Breakdown, description string, amount float64) Breakdown {
+	amount = round(min(amount, breakdown.Subtotal))
+	if amount <= 0 {
+		return breakdown
+	}
+
+	discounted := Breakdown{
+		Lines:    slices.Clone(breakdown.Lines),
+		Subtotal: breakdown.Subtotal,
+		Discount: breakdown.Discount,
+	}
+	discounted.add(description, 1, -amount)
+	discounted.Discount = round(discounted.Discount + amount)
+	c.addTax(&discounted)
+
+	return discounted
+}
+
+func (c *Calculator) addTax(breakdown *Breakdown) {
+	breakdown.Tax = round(breakdown.Subtotal * c.rules.TaxRate)
+	breakdown.Total = round(breakdown.Subtotal + breakdown.Tax)
+}
+
+// billedDuration rounds duration up to whole increments once it is past the
+// grace period of the last one.
+func (c *Calculator) billedDuration(duration time.Duration) time.Duration {
+	if c.rules.Increment <= 0 {
+		return duration
+	}
+
+	increments := duration / c.rules.Increment
+	if duration%c.rules.Increment > c.rules.Grace {
+		increments++
+	}
+
+	return increments * c.rules.Increment
+}
+
+// splitWeekend tells how many of the hours between start and start+length
+// fall on weekdays and how many on the weekend, in the time zone of start.
+func (c *Calculator) splitWeekend(start time.Time, length time.Duration) (float64, float64) {
+	var weekend time.Duration
+	end := start.Add(length)
+	for current := start; current.Before(end); {
+		year, month, date := current.Date()
+		next := time.Date(year, month, date+1, 0, 0, 0, 0, current.Location())
+		if next.After(end) {
+			next = end
+		}
+
+		if weekday := current.Weekday(); weekday == time.Saturday || weekday == time.Sunday {
+			weekend += next.Sub(current)
+		}
+		current = next
+	}
+
+	return (length - weekend).Hours(), weekend.Hours()
+}
+
+// add adds quantity units at unitPrice, merging it into the line with the same
+// description and price if there is one.
+func (b *Breakdown) add(description string, quantity float64, unitPrice float64) {
+	if quantity == 0 {
+		return
+	}
+
+	amount := round(quantity * unitPrice)
+	b.Subtotal = round(b.Subtotal + amount)
+	for i, line := range b.Lines {
+		if line.Description == description && line.UnitPrice == unitPrice {
+			b.Lines[i].Quantity += quantity
+			b.Lines[i].Amount = round(line.Amount + amount)
+			return
+		}
+	}
+
+	b.Lines = append(b.Lines, Line{
+		Description: description,
+		Quantity:    quantity,
+		UnitPrice:   unitPrice,
+		Amount:      amount,
+	})
+}
+
+// round rounds amount to cents.
+func round(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
@@ -0,0 +1,272 @@
+package pricing
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+var testRules = Rules{
+	Increment: time.Hour,
+	Grace:     15 * time.Minute,
+	TaxRate:   0.19,
+}
+
+// at is a time in January 2024, which starts on a Monday.
+func at(date int, hour int, minute int) time.Time {
+	return time.Date(2024, time.January, date, hour, minute, 0, 0, time.UTC)
+}
+
+func TestPrice(t *testing.T) {
+	tests := []struct {
+		name string
+		plan Plan
+		from time.Time
+		to   time.Time
+		want Breakdown
+	}{
+		{
+			name: "hourly",
+			plan: Plan{HourlyFare: 10},
+			from: at(1, 10, 0),
+			to:   at(1, 13, 0),
+			want: Breakdown{
+				Lines:    []Line{{"Hourly rate", 3, 10, 30}},
+				Subtotal: 30,
+				Tax:      5.7,
+				Total:    35.7,
+			},
+		},
+		{
+			name: "within grace",
+			plan: Plan{HourlyFare: 10},
+			from: at(1, 10, 0),
+			to:   at(1, 13, 10),
+			want: Breakdown{
+				Lines:    []Line{{"Hourly rate", 3, 10, 30}},
+				Subtotal: 30,
+				Tax:      5.7,
+				Total:    35.7,
+			},
+		},
+		{
+			name: "past grace bills the whole increment",
+			plan: Plan{HourlyFare: 10},
+			from: at(1, 10, 0),
+			to:   at(1, 13, 20),
+			want: Breakdown{
+				Lines:    []Line{{"Hourly rate", 4, 10, 40}},
+				Subtotal: 40,
+				Tax:      7.6,
+				Total:    47.6,
+			},
+		},
+		{
+			name: "minimum duration",
+			plan: Plan{HourlyFare: 10, MinimumDuration: 4 * time.Hour},
+			from: at(1, 10, 0),
+			to:   at(1, 12, 0),
+			want: Breakdown{
+				Lines:    []Line{{"Hourly rate", 4, 10, 40}},
+				Subtotal: 40,
+				Tax:      7.6,
+				Total:    47.6,
+			},
+		},
+		{
+			name: "daily cap",
+			plan: Plan{HourlyFare: 10, DailyCap: 150},
+			from: at(1, 0, 0),
+			to:   at(3, 6, 0),
+			want: Breakdown{
+				Lines: []Line{
+					{"Daily cap", 2, 150, 300},
+					{"Hourly rate", 6, 10, 60},
+				},
+				Subtotal: 360,
+				Tax:      68.4,
+				Total:    428.4,
+			},
+		},
+		{
+			name: "weekly rate with capped days left",
+			plan: Plan{HourlyFare: 10, DailyCap: 150, WeeklyRate: 700},
+			from: at(1, 0, 0),
+			to:   at(9, 20, 0),
+			want: Breakdown{
+				Lines: []Line{
+					{"Weekly rate", 1, 700, 700},
+					{"Daily cap", 2, 150, 300},
+				},
+				Subtotal: 1000,
+				Tax:      190,
+				Total:    1190,
+			},
+		},
+		{
+			name: "week without weekly rate is capped day by day",
+			plan: Plan{HourlyFare: 10, DailyCap: 150},
+			from: at(1, 0, 0),
+			to:   at(8, 0, 0),
+			want: Breakdown{
+				Lines:    []Line{{"Daily cap", 7, 150, 1050}},
+				Subtotal: 1050,
+				Tax:      199.5,
+				Total:    1249.5,
+			},
+		},
+		{
+			name: "crossing into the weekend",
+			plan: Plan{HourlyFare: 10, WeekendHourlyFare: 15},
+			from: at(5, 20, 0),
+			to:   at(6, 6, 0),
+			want: Breakdown{
+				Lines: []Line{
+					{"Hourly rate", 4, 10, 40},
+					{"Weekend hourly rate", 6, 15, 90},
+				},
+				Subtotal: 130,
+				Tax:      24.7,
+				Total:    154.7,
+			},
+		},
+		{
+			name: "weekend day over the daily cap",
+			plan: Plan{HourlyFare: 10, WeekendHourlyFare: 15, DailyCap: 200},
+			from: at(6, 0, 0),
+			to:   at(7, 0, 0),
+			want: Breakdown{
+				Lines:    []Line{{"Daily cap", 1, 200, 200}},
+				Subtotal: 200,
+				Tax:      38,
+				Total:    238,
+			},
+		},
+		{
+			name: "tax rounded to cents",
+			plan: Plan{HourlyFare: 10.33},
+			from: at(1, 10, 0),
+			to:   at(1, 11, 0),
+			want: Breakdown{
+				Lines:    []Line{{"Hourly rate", 1, 10.33, 10.33}},
+				Subtotal: 10.33,
+				Tax:      1.96,
+				Total:    12.29,
+			},
+		},
+	}
+
+	calculator := NewCalculator(testRules)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := calculator.Price(tt.plan, tt.from, tt.to)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiscount(t *testing.T) {
+	calculator := NewCalculator(testRules)
+	base := calculator.Price(Plan{HourlyFare: 10}, at(1, 10, 0), at(1, 13, 0))
+
+	tests := []struct {
+		name   string
+		amount float64
+		want   Breakdown
+	}{
+		{
+			name:   "partial",
+			amount: 5,
+			want: Breakdown{
+				Lines: []Line{
+					{"Hourly rate", 3, 10, 30},
+					{"Promo", 1, -5, -5},
+				},
+				Subtotal: 25,
+				Discount: 5,
+				Tax:      4.75,
+				Total:    29.75,
+			},
+		},
+		{
+			name:   "larger than the subtotal",
+			amount: 50,
+			want: Breakdown{
+				Lines: []Line{
+					{"Hourly rate", 3, 10, 30},
+					{"Promo", 1, -30, -30},
+				},
+				Discount: 30,
+			},
+		},
+		{
+			name:   "nothing off",
+			amount: 0,
+			want:   base,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := calculator.Discount(base, "Promo", tt.amount)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+
+			if len(base.Lines) != 1 {
+				t.Errorf("discount changed the lines of the original breakdown")
+			}
+		})
+	}
+}
+
+func TestBilledDuration(t *testing.T) {
+	tests := []struct {
+		name     string
+		rules    Rules
+		duration time.Duration
+		want     time.Duration
+	}{
+		{"whole increments", testRules, 2 * time.Hour, 2 * time.Hour},
+		{"within grace", testRules, 2*time.Hour + 10*time.Minute, 2 * time.Hour},
+		{"at the end of grace", testRules, 2*time.Hour + 15*time.Minute, 2 * time.Hour},
+		{"past grace", testRules, 2*time.Hour + 16*time.Minute, 3 * time.Hour},
+		{"shorter than grace", testRules, 10 * time.Minute, 0},
+		{"no increment", Rules{}, 2*time.Hour + 16*time.Minute, 2*time.Hour + 16*time.Minute},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NewCalculator(tt.rules).billedDuration(tt.duration); got != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSplitWeekend(t *testing.T) {
+	tests := []struct {
+		name        string
+		start       time.Time
+		length      time.Duration
+		wantWeekday float64
+		wantWeekend float64
+	}{
+		{"weekday", at(1, 10, 0), 5 * time.Hour, 5, 0},
+		{"friday into saturday", at(5, 20, 0), 10 * time.Hour, 4, 6},
+		{"whole saturday", at(6, 0, 0), 24 * time.Hour, 0, 24},
+		{"sunday into monday", at(7, 22, 0), 5 * time.Hour, 3, 2},
+	}
+
+	calculator := NewCalculator(testRules)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			weekday, weekend := calculator.splitWeekend(tt.start, tt.length)
+			if weekday != tt.wantWeekday || weekend != tt.wantWeekend {
+				t.Errorf("got %v weekday and %v weekend hours, want %v and %v", weekday, weekend, tt.wantWeekday, tt.wantWeekend)
+			}
+		})
+	}
+}
@@ -0,0 +1,100 @@
+package pricing
+
+import (
+	"time"
+
+	"backend/src/commons"
+
+	"github.com/golang-jwt/jwt"
+)
+
+const quoteAudience = "booking-quote"
+
+// Quote is a price offered for renting a vehicle between two dates. Its signed
+// id lets the booking be created at that price until the quote expires. Plan
+// is the rates it was priced with, which the booking keeps.
+type Quote struct {
+	VehicleID uint
+	StartDate time.Time
+	EndDate   time.Time
+	Plan      Plan
+	Breakdown Breakdown
+	Deposit   float64
+	ExpiresAt time.Time
+}
+
+type quoteClaims struct {
+	VehicleID uint      `json:"vehicle_id"`
+	StartDate time.Time `json:"start_date"`
+	EndDate   time.Time `json:"end_date"`
+	Plan      Plan      `json:"plan"`
+	Breakdown Breakdown `json:"breakdown"`
+	Deposit   float64   `json:"deposit"`
+	jwt.StandardClaims
+}
+
+// Quotes signs quotes so clients can hand them back without the server
+// storing them.
+type Quotes struct {
+	secret []byte
+	ttl    time.Duration
+}
+
+func NewQuotes(secret string, ttl time.Duration) *Quotes {
+	return &Quotes{
+		secret: []byte(secret),
+		ttl:    ttl,
+	}
+}
+
+// Issue sets the expiration of quote and returns its signed id.
+func (q *Quotes) Issue(quote Quote) (string, *Quote, error) {
+	now := time.Now()
+	quote.ExpiresAt = now.Add(q.ttl)
+
+	claims := quoteClaims{
+		VehicleID: quote.VehicleID,
+		StartDate: quote.StartDate,
+		EndDate:   quote.EndDate,
+		Plan:      quote.Plan,
+		Breakdown: quote.Breakdown,
+		Deposit:   quote.Deposit,
+		StandardClaims: jwt.StandardClaims{
+			Audience:  quoteAudience,
+			IssuedAt:  now.Unix(),
+			ExpiresAt: quote.ExpiresAt.Unix(),
+		},
+	}
+
+	id, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(q.secret)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return id, &quote, nil
+}
+
+// Verify returns the quote signed as id, unless it was tampered with or
+// already expired.
+func (q *Quotes) Verify(id string) (*Quote, error) {
+	claims := &quoteClaims{}
+	parsed, err := jwt.ParseWithClaims(id, claims, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, commons.ErrInvalidQuote
+		}
+		return q.secret, nil
+	})
+	if err != nil || !parsed.Valid || !claims.VerifyAudience(quoteAudience, true) {
+		return nil, commons.ErrInvalidQuote
+	}
+
+	return &Quote{
+		VehicleID: claims.VehicleID,
+		StartDate: claims.StartDate,
+		EndDate:   claims.EndDate,
+		Plan:      claims.Plan,
+		Breakdown: claims.Breakdown,
+		Deposit:   claims.Deposit,
+		ExpiresAt: time.Unix(claims.ExpiresAt, 0),
+	}, nil
+}
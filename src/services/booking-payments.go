@@ -0,0 +1,313 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"backend/src/commons"
+	"backend/src/domain"
+)
+
+// authorizePayment puts amount on hold for booking through the payment
+// provider. The payment is not stored.
+func (b *BookingsService) authorizePayment(booking domain.Booking, kind string, amount float64) (*domain.Payment, error) {
+	transaction, err := b.Payments.Authorize(amount, fmt.Sprintf("booking-%d", booking.ID))
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.Payment{
+		BookingID:         booking.ID,
+		Provider:          b.Payments.Name(),
+		ProviderReference: transaction.ID,
+		Kind:              kind,
+		Status:            transaction.Status,
+		Amount:            amount,
+	}, nil
+}
+
+// authorizeHolds puts the total and the deposit of booking on hold. If either
+// is declined nothing is kept on hold. The payments are not stored.
+func (b *BookingsService) authorizeHolds(booking domain.Booking) ([]domain.Payment, error) {
+	amounts := []struct {
+		kind   string
+		amount float64
+	}{
+		{commons.PaymentKindRental, booking.TotalAmount},
+		{commons.PaymentKindDeposit, booking.DepositAmount},
+	}
+
+	holds := make([]domain.Payment, 0, len(amounts))
+	for _, hold := range amounts {
+		if hold.amount <= 0 {
+			continue
+		}
+
+		payment, err := b.authorizePayment(booking, hold.kind, hold.amount)
+		if err != nil {
+			b.releaseHolds(booking, holds)
+			return nil, err
+		}
+
+		holds = append(holds, *payment)
+	}
+
+	return holds, nil
+}
+
+// staleHolds returns the rental and deposit authorizations held for booking if
+// they no longer add up to its total and deposit, e.g. after it was
+// rescheduled.
+func staleHolds(booking domain.Booking) []domain.Payment {
+	held := make(map[string]float64)
+	holds := make([]domain.Payment, 0)
+	for _, payment := range booking.Payments {
+		if payment.Status != commons.PaymentStatusAuthorized {
+			continue
+		}
+
+		if payment.Kind == commons.PaymentKindRental || payment.Kind == commons.PaymentKindDeposit {
+			held[payment.Kind] += payment.Amount
+			holds = append(holds, payment)
+		}
+	}
+
+	if len(holds) == 0 {
+		return nil
+	}
+
+	if roundCents(held[commons.PaymentKindRental]) == booking.TotalAmount &&
+		roundCents(held[commons.PaymentKindDeposit]) == booking.DepositAmount {
+		return nil
+	}
+
+	return holds
+}
+
+// releaseHolds voids holds that were never stored.
+func (b *BookingsService) releaseHolds(booking domain.Booking, holds []domain.Payment) {
+	for _, hold := range holds {
+		if _, err := b.Payments.Void(hold.ProviderReference); err != nil {
+			b.reportPaymentFailure(booking, "void", err)
+		}
+	}
+}
+
+// capturePayments charges the total of a finished booking against its rental
+// authorizations. Whatever they do not cover, e.g. after an extension or a
+// late return, is charged as a payment of its own. Failed charges are reported
+// to admins instead of failing the return of the vehicle. It returns the
+// payments that changed.
+func (b *BookingsService) capturePayments(booking *domain.Booking) []domain.Payment {
+	due := booking.TotalAmount
+	for _, payment := range booking.Payments {
+		if payment.Kind == commons.PaymentKindRental {
+			due -= payment.CapturedAmount
+		}
+	}
+
+	captured, _, changed := b.captureHolds(booking, commons.PaymentKindRental, due)
+	if due = roundCents(due - captured); due > 0 {
+		payment := b.chargePayment(*booking, commons.PaymentKindRental, due)
+		booking.Payments = append(booking.Payments, payment)
+		changed = append(changed, payment)
+	}
+
+	return changed
+}
+
+// settleDeposit pays the charges posted on a finished booking out of its
+// deposit and releases the rest, recording the settlement on the booking.
+// Charges over the deposit are charged as a payment of their own. It returns
+// the payments that changed.
+func (b *BookingsService) settleDeposit(booking *domain.Booking, now time.Time) []domain.Payment {
+	settlement := domain.DepositSettlement{
+		BookingID: booking.ID,
+		SettledAt: now,
+	}
+	for _, charge := range booking.Charges {
+		settlement.ChargedAmount += charge.Amount
+	}
+	settlement.ChargedAmount = roundCents(settlement.ChargedAmount)
+
+	for _, payment := range booking.Payments {
+		if payment.Kind == commons.PaymentKindDeposit && payment.Status == commons.PaymentStatusAuthorized {
+			settlement.HeldAmount += payment.Amount
+		}
+	}
+
+	captured, released, changed := b.captureHolds(booking, commons.PaymentKindDeposit, settlement.ChargedAmount)
+	settlement.CapturedAmount = captured
+	settlement.ReleasedAmount = released
+
+	if due := roundCents(settlement.ChargedAmount - captured); due > 0 {
+		payment := b.chargePayment(*booking, commons.PaymentKindCharges, due)
+		if payment.Status == commons.PaymentStatusCaptured {
+			settlement.ExtraChargedAmount = due
+		}
+
+		booking.Payments = append(booking.Payments, payment)
+		changed = append(changed, payment)
+	}
+
+	booking.Settlement = &settlement
+	return changed
+}
+
+// captureHolds captures up to due from the authorizations of kind held for
+// booking and releases whatever is left of them. It returns how much was
+// captured and released, and the payments that changed.
+func (b *BookingsService) captureHolds(booking *domain.Booking, kind string, due float64) (float64, float64, []domain.Payment) {
+	var captured, released float64
+	changed := make([]domain.Payment, 0)
+	for i := range booking.Payments {
+		payment := &booking.Payments[i]
+		if payment.Kind != kind || payment.Status != commons.PaymentStatusAuthorized {
+			continue
+		}
+
+		amount := roundCents(min(max(due-captured, 0), payment.Amount))
+		if amount > 0 {
+			if transaction, err := b.Payments.Capture(payment.ProviderReference, amount); err != nil {
+				payment.Status = commons.PaymentStatusFailed
+				b.reportPaymentFailure(*booking, "capture", err)
+			} else {
+				payment.Status = transaction.Status
+				payment.CapturedAmount = amount
+				captured += amount
+				released += payment.Amount - amount
+			}
+		} else if b.voidPayment(*booking, payment) {
+			released += payment.Amount
+		}
+
+		changed = append(changed, *payment)
+	}
+
+	return roundCents(captured), roundCents(released), changed
+}
+
+// settleCancellation collects the cancellation fee of booking out of what was
+// paid or put on hold for it, and gives back the rest: holds are released and
+// captured payments are refunded. It returns the payments that changed.
+func (b *BookingsService) settleCancellation(booking *domain.Booking) []domain.Payment {
+	paid := 0.0
+	for _, payment := range booking.Payments {
+		if payment.Kind == commons.PaymentKindRental {
+			paid += payment.CapturedAmount - refundedAmount(*booking, payment.ID)
+		}
+	}
+
+	captured, _, changed := b.captureHolds(booking, commons.PaymentKindRental, max(booking.CancellationFee-paid, 0))
+	_, _, released := b.captureHolds(booking, commons.PaymentKindDeposit, 0)
+	changed = append(changed, released...)
+
+	if refundable := roundCents(paid + captured - booking.CancellationFee); refundable > 0 {
+		b.refundPayments(booking, refundable)
+	}
+
+	return changed
+}
+
+// refundPayments refunds amount out of the captured rental payments of
+// booking, adding the refunds to it.
+func (b *BookingsService) refundPayments(booking *domain.Booking, amount float64) {
+	for _, payment := range booking.Payments {
+		if amount <= 0 {
+			return
+		}
+
+		if payment.Kind != commons.PaymentKindRental || payment.Status != commons.PaymentStatusCaptured {
+			continue
+		}
+
+		refundable := roundCents(min(amount, payment.CapturedAmount-refundedAmount(*booking, payment.ID)))
+		if refundable <= 0 {
+			continue
+		}
+
+		refund := domain.Refund{
+			BookingID: booking.ID,
+			PaymentID: payment.ID,
+			Provider:  b.Payments.Name(),
+			Amount:    refundable,
+		}
+		if transaction, err := b.Payments.Refund(payment.ProviderReference, refundable); err != nil {
+			refund.Status = commons.RefundStatusFailed
+			b.reportPaymentFailure(*booking, "refund", err)
+		} else {
+			refund.ProviderReference = transaction.ID
+			refund.Status = transaction.Status
+			amount = roundCents(amount - refundable)
+		}
+
+		booking.Refunds = append(booking.Refunds, refund)
+	}
+}
+
+// refundedAmount is how much of the payment with paymentID was given back,
+// or is being given back, to the customer.
+func refundedAmount(booking domain.Booking, paymentID uint) float64 {
+	refunded := 0.0
+	for _, refund := range booking.Refunds {
+		if refund.PaymentID == paymentID && refund.Status != commons.RefundStatusFailed {
+			refunded += refund.Amount
+		}
+	}
+
+	return refunded
+}
+
+// chargePayment authorizes and captures amount at once. A failed charge is
+// still returned, so it is recorded on the booking.
+func (b *BookingsService) chargePayment(booking domain.Booking, kind string, amount float64) domain.Payment {
+	payment, err := b.authorizePayment(booking, kind, amount)
+	if err != nil {
+		b.reportPaymentFailure(booking, "charge", err)
+		return domain.Payment{
+			BookingID: booking.ID,
+			Provider:  b.Payments.Name(),
+			Kind:      kind,
+			Status:    commons.PaymentStatusFailed,
+			Amount:    amount,
+		}
+	}
+
+	transaction, err := b.Payments.Capture(payment.ProviderReference, amount)
+	if err != nil {
+		b.reportPaymentFailure(booking, "charge", err)
+		payment.Status = commons.PaymentStatusFailed
+		return *payment
+	}
+
+	payment.Status = transaction.Status
+	payment.CapturedAmount = amount
+	return *payment
+}
+
+// voidPayment releases the authorization of payment, reporting whether it
+// could.
+func (b *BookingsService) voidPayment(booking domain.Booking, payment *domain.Payment) bool {
+	transaction, err := b.Payments.Void(payment.ProviderReference)
+	if err != nil {
+		payment.Status = commons.PaymentStatusFailed
+		b.reportPaymentFailure(booking, "void", err)
+		return false
+	}
+
+	payment.Status = transaction.Status
+	return true
+}
+
+// reportPaymentFailure lets admins know a payment operation on booking failed
+// and needs to be settled by hand.
+func (b *BookingsService) reportPaymentFailure(booking domain.Booking, operation string, err error) {
+	subject := fmt.Sprintf("Payment %s failed for booking %d", operation, booking.ID)
+	_ = b.Notifier.NotifyAdmins(subject, err.Error())
+}
+
+// roundCents rounds amount to cents.
+func roundCents(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
@@ -0,0 +1,175 @@
+package services
+
+import (
+	"slices"
+	"time"
+
+	"backend/src/commons"
+	"backend/src/domain"
+	"backend/src/policy"
+)
+
+const (
+	BookingEventCancel  = "cancel"
+	BookingEventConfirm = "confirm"
+	BookingEventPickUp  = "pick-up"
+	BookingEventFinish  = "finish"
+	BookingEventExpire  = "expire"
+	BookingEventNoShow  = "no-show"
+	BookingEventOverdue = "overdue"
+)
+
+// bookingTransition declares one event of the booking state machine: the
+// statuses it can fire from, the status it leads to, who may trigger it and
+// any extra condition the booking must meet. Events with an empty Action can
+// only be triggered by the system.
+type bookingTransition struct {
+	From   []string
+	To     string
+	Action policy.Action
+	Verb   string
+	Guard  func(booking domain.Booking, now time.Time) error
+}
+
+var bookingTransitions = map[string]bookingTransition{
+	BookingEventCancel: {
+		From:   []string{commons.BookingStatusReserved, commons.BookingStatusConfirmed},
+		To:     commons.BookingStatusCancelled,
+		Action: policy.CancelBooking,
+		Verb:   "cancelled",
+	},
+	BookingEventConfirm: {
+		From:   []string{commons.BookingStatusReserved},
+		To:     commons.BookingStatusConfirmed,
+		Action: policy.ConfirmBooking,
+		Verb:   "confirmed",
+		Guard: func(booking domain.Booking, now time.Time) error {
+			if !booking.EndDate.After(now) {
+				return commons.ErrBookingDatesPassed
+			}
+			return nil
+		},
+	},
+	BookingEventPickUp: {
+		From:   []string{commons.BookingStatusConfirmed},
+		To:     commons.BookingStatusInProgress,
+		Action: policy.PickUpBooking,
+		Verb:   "picked up",
+		Guard: func(booking domain.Booking, now time.Time) error {
+			if !booking.EndDate.After(now) {
+				return commons.ErrBookingDatesPassed
+			}
+			return nil
+		},
+	},
+	BookingEventFinish: {
+		From:   []string{commons.BookingStatusInProgress, commons.BookingStatusOverdue},
+		To:     commons.BookingStatusFinished,
+		Action: policy.FinishBooking,
+		Verb:   "finished",
+	},
+	BookingEventExpire: {
+		From: []string{commons.BookingStatusReserved},
+		To:   commons.BookingStatusExpired,
+		Verb: "expired",
+	},
+	BookingEventNoShow: {
+		From: []string{commons.BookingStatusConfirmed},
+		To:   commons.BookingStatusNoShow,
+		Verb: "marked as no show",
+	},
+	BookingEventOverdue: {
+		From: []string{commons.BookingStatusInProgress},
+		To:   commons.BookingStatusOverdue,
+		Verb: "marked as overdue",
+	},
+}
+
+// applyBookingEvent moves booking through event and appends the change to its
+// history. A nil actor means the system triggers the event. The caller is
+// responsible for saving the booking.
+func applyBookingEvent(booking *domain.Booking, event string, actor *domain.User, reason string, now time.Time) error {
+	transition, ok := bookingTransitions[event]
+	if !ok {
+		return commons.ErrInvalidBookingTransition
+	}
+
+	if actor != nil && !policy.Can(*actor, transition.Action, booking.UserID) {
+		return commons.ErrForbidden
+	}
+
+	if !slices.Contains(transition.From, booking.Status) {
+		return invalidBookingTransitionError(event, booking.Status)
+	}
+
+	if transition.Guard != nil {
+		if err := transition.Guard(*booking, now); err != nil {
+			return err
+		}
+	}
+
+	if reason == "" {
+		reason = "Booking " + transition.Verb + " by " + actorName(actor)
+	}
+
+	var actorID *uint
+	if actor != nil {
+		actorID = &actor.ID
+	}
+
+	booking.StatusChanges = append(booking.StatusChanges, domain.BookingStatusChange{
+		BookingID:  booking.ID,
+		ActorID:    actorID,
+		FromStatus: booking.Status,
+		ToStatus:   transition.To,
+		Reason:     reason,
+	})
+	booking.Status = transition.To
+	booking.Observations = &reason
+
+	return nil
+}
+
+// invalidBookingTransitionError explains why event cannot fire while the
+// booking is in status.
+func invalidBookingTransitionError(event string, status string) error {
+	switch status {
+	case commons.BookingStatusCancelled:
+		return commons.ErrBookingAlreadyCancelled
+	case commons.BookingStatusExpired:
+		return commons.ErrBookingExpired
+	case commons.BookingStatusNoShow:
+		return commons.ErrBookingNoShow
+	case commons.BookingStatusFinished:
+		return commons.ErrBookingAlreadyFinished
+	case commons.BookingStatusReserved:
+		if event == BookingEventPickUp {
+			return commons.ErrBookingNotConfirmed
+		}
+		if event == BookingEventFinish {
+			return commons.ErrBookingNotStarted
+		}
+	case commons.BookingStatusConfirmed:
+		if event == BookingEventFinish {
+			return commons.ErrBookingNotStarted
+		}
+		return commons.ErrBookingAlreadyConfirmed
+	case commons.BookingStatusInProgress, commons.BookingStatusOverdue:
+		return commons.ErrBookingAlreadyStarted
+	}
+
+	return commons.ErrInvalidBookingTransition
+}
+
+// actorName describes who triggered a change, as shown in booking history.
+func actorName(user *domain.User) string {
+	if user == nil {
+		return "system"
+	}
+
+	if user.Type == commons.UserTypeClient {
+		return "user"
+	}
+
+	return user.Type
+}
@@ -1,48 +1,293 @@
 package services
 
 import (
+	"errors"
+	"fmt"
 	"time"
 
 	"backend/src/commons"
 	"backend/src/domain"
 	"backend/src/handlers/requests"
+	"backend/src/payments"
+	"backend/src/policy"
+	"backend/src/pricing"
 )
 
+// VehicleFilter narrows the vehicles returned by an availability search.
+// Zero values mean no restriction.
+type VehicleFilter struct {
+	From             time.Time
+	To               time.Time
+	Brand            string
+	Type             string
+	TransmissionType string
+	MinYear          int
+	MaxYear          int
+	MaxHourlyFare    float64
+	Branch           string
+	Turnaround       Turnaround
+	Sort             string
+	Offset           int
+	Limit            int
+}
+
+// BookingFilter narrows a bookings listing. Zero values mean no restriction;
+// From and To keep the bookings whose dates overlap that range. Overdue keeps
+// the rentals past their end date, flagged or not yet flagged.
+type BookingFilter struct {
+	UserID    uint
+	Status    string
+	VehicleID uint
+	From      time.Time
+	To        time.Time
+	Overdue   bool
+	Now       time.Time
+	Sort      string
+	Offset    int
+	Limit     int
+}
+
+// Turnaround is the time a vehicle needs between two rentals for cleaning and
+// inspection. ByVehicleType overrides Default for some vehicle types.
+type Turnaround struct {
+	Default       time.Duration
+	ByVehicleType map[string]time.Duration
+}
+
+// For returns the turnaround of vehicles of vehicleType.
+func (t Turnaround) For(vehicleType string) time.Duration {
+	if buffer, ok := t.ByVehicleType[vehicleType]; ok {
+		return buffer
+	}
+
+	return t.Default
+}
+
+// Deposits is the amount held against damages when renting a vehicle.
+// ByVehicleType overrides Default for some vehicle types.
+type Deposits struct {
+	Default       float64
+	ByVehicleType map[string]float64
+}
+
+// For returns the deposit of vehicles of vehicleType.
+func (d Deposits) For(vehicleType string) float64 {
+	if deposit, ok := d.ByVehicleType[vehicleType]; ok {
+		return deposit
+	}
+
+	return d.Default
+}
+
+// CancellationPolicy decides what cancelling a booking costs. Bookings are
+// cancelled for free until FreePeriod before they start, and for LateFee
+// times their total after that. Bookings that are not picked up, or that are
+// cancelled once they should have started, are charged NoShowFee times their
+// total.
+type CancellationPolicy struct {
+	FreePeriod time.Duration
+	LateFee    float64
+	NoShowFee  float64
+}
+
+// Fee is what cancelling booking at now costs.
+func (c CancellationPolicy) Fee(booking domain.Booking, now time.Time) float64 {
+	if now.Before(booking.StartDate.Add(-c.FreePeriod)) {
+		return 0
+	}
+
+	if now.Before(booking.StartDate) {
+		return roundCents(booking.TotalAmount * c.LateFee)
+	}
+
+	return c.NoShow(booking)
+}
+
+// NoShow is what not picking up booking costs.
+func (c CancellationPolicy) NoShow(booking domain.Booking) float64 {
+	return roundCents(booking.TotalAmount * c.NoShowFee)
+}
+
 type BookingsDatabase interface {
-	GetAvailableVehicles(from time.Time, to time.Time) ([]domain.Vehicle, error)
-	GetUserById(id uint) (*domain.User, error)
+	GetAvailableVehicles(filter VehicleFilter) ([]domain.Vehicle, int64, error)
 	GetVehicleById(id uint) (*domain.Vehicle, error)
+	GetPromotionByCode(code string) (*domain.Promotion, error)
 	GetBookingById(id uint) (*domain.Booking, error)
-	CreateBooking(booking domain.Booking) (*domain.Booking, error)
+	CreateBooking(booking domain.Booking, turnaround Turnaround) (*domain.Booking, error)
+	RescheduleBooking(booking domain.Booking, turnaround Turnaround, payments ...domain.Payment) (*domain.Booking, error)
+	SaveBookingExtension(booking domain.Booking, extension domain.BookingExtension, turnaround Turnaround) (*domain.Booking, error)
+	GetOverlappingBookings(vehicleID uint, from time.Time, to time.Time) ([]domain.Booking, error)
+	GetMaintenanceWindowsByVehicleID(vehicleID uint) ([]domain.MaintenanceWindow, error)
 	UpdateBooking(booking domain.Booking) (*domain.Booking, error)
-	GetBookingsByUserID(userID uint) ([]domain.Booking, error)
-	GetAdminBookings() ([]domain.Booking, error)
+	TransitionBooking(booking domain.Booking, fromStatus string) (bool, error)
+	SaveBookingPayments(booking domain.Booking, payments ...domain.Payment) (*domain.Booking, error)
+	SaveBookingTransition(booking domain.Booking, fromStatus string, payments ...domain.Payment) (*domain.Booking, error)
+	GetPendingRefunds() ([]domain.Refund, error)
+	UpdateRefund(refund domain.Refund) (*domain.Refund, error)
+	GetPaymentByReference(provider string, reference string) (*domain.Payment, error)
+	UpdatePayment(payment domain.Payment) (*domain.Payment, error)
+	GetRefundByReference(provider string, reference string) (*domain.Refund, error)
+	ClaimWebhookEvent(event domain.WebhookEvent, process func(database BookingsDatabase) error) (bool, error)
+	GetBookings(filter BookingFilter) ([]domain.Booking, int64, error)
+	GetExpiredReservations(createdBefore time.Time, now time.Time) ([]domain.Booking, error)
+	GetConfirmedBookingsStartedBefore(startBefore time.Time) ([]domain.Booking, error)
+	GetInProgressBookingsEndedBefore(endBefore time.Time) ([]domain.Booking, error)
+}
+
+type Notifier interface {
+	NotifyAdmins(subject string, message string) error
+}
+
+type BookingsConfig struct {
+	// ReservationHold is how long a reservation waits for confirmation
+	// before it expires and frees its vehicle.
+	ReservationHold time.Duration
+	// NoShowGrace is how long after its start date a confirmed booking can
+	// still be picked up before it is flagged as a no show.
+	NoShowGrace time.Duration
+	// OverdueGrace is how long after its end date a rental can still be
+	// returned before it is flagged as overdue.
+	OverdueGrace time.Duration
+	// MaxAutoExtension is the longest extension approved without review.
+	// Staff who can review extensions get theirs approved at any length.
+	MaxAutoExtension time.Duration
+	// Turnaround keeps vehicles free for a while before and after every
+	// booking.
+	Turnaround   Turnaround
+	Deposits     Deposits
+	Cancellation CancellationPolicy
 }
 
 type BookingsService struct {
 	Database BookingsDatabase
+	Notifier Notifier
+	Pricing  *pricing.Calculator
+	Quotes   *pricing.Quotes
+	Payments payments.PaymentProvider
+	Config   BookingsConfig
 }
 
-func NewBookingsService(database BookingsDatabase) *BookingsService {
+func NewBookingsService(database BookingsDatabase, notifier Notifier, calculator *pricing.Calculator, quotes *pricing.Quotes, provider payments.PaymentProvider, config BookingsConfig) *BookingsService {
 	return &BookingsService{
 		Database: database,
+		Notifier: notifier,
+		Pricing:  calculator,
+		Quotes:   quotes,
+		Payments: provider,
+		Config:   config,
 	}
 }
 
-func (b *BookingsService) GetAvailableVehicles(from time.Time, to time.Time) ([]domain.Vehicle, error) {
-	return b.Database.GetAvailableVehicles(from, to)
+func (b *BookingsService) GetAvailableVehicles(request requests.AvailableVehiclesRequest) ([]domain.Vehicle, int64, error) {
+	return b.Database.GetAvailableVehicles(VehicleFilter{
+		From:             request.From,
+		To:               request.To,
+		Brand:            request.Brand,
+		Type:             request.Type,
+		TransmissionType: request.TransmissionType,
+		MinYear:          request.MinYear,
+		MaxYear:          request.MaxYear,
+		MaxHourlyFare:    request.MaxHourlyFare,
+		Branch:           request.Branch,
+		Turnaround:       b.Config.Turnaround,
+		Sort:             request.Sort,
+		Offset:           request.Offset(),
+		Limit:            request.Limit(),
+	})
 }
 
-func (b *BookingsService) CreateBooking(request requests.CreateBookingRequest) (*domain.Booking, error) {
-	user, err := b.Database.GetUserById(request.UserID)
+// QuoteBooking prices renting a vehicle between two dates and signs the
+// price so a booking can be created at it while the quote lasts. A promo code
+// is shown applied, but only counts as used once a booking is created with it.
+func (b *BookingsService) QuoteBooking(request requests.QuoteBookingRequest) (string, *pricing.Quote, error) {
+	vehicle, err := b.Database.GetVehicleById(request.VehicleID)
 	if err != nil {
-		return nil, err
+		return "", nil, err
+	}
+
+	if vehicle == nil {
+		return "", nil, commons.ErrVehicleNotFound
 	}
 
-	if user == nil {
-		return nil, commons.ErrUserNotFound
+	if vehicle.Status != commons.VehicleStatusAvailable {
+		return "", nil, commons.ErrVehicleNotAvailable
 	}
 
+	plan := pricing.VehiclePlan(*vehicle)
+	if request.EndDate.Sub(request.StartDate) < plan.MinimumDuration {
+		return "", nil, commons.ErrBookingTooShort
+	}
+
+	available, err := b.vehicleAvailable(*vehicle, request.StartDate, request.EndDate)
+	if err != nil {
+		return "", nil, err
+	}
+
+	if !available {
+		return "", nil, commons.ErrVehicleNotAvailable
+	}
+
+	var promotion *domain.Promotion
+	if request.PromoCode != "" {
+		promotion, err = b.findPromotion(request.PromoCode, vehicle.Type, time.Now())
+		if err != nil {
+			return "", nil, err
+		}
+	}
+
+	id, quote, err := b.Quotes.Issue(pricing.Quote{
+		VehicleID: vehicle.ID,
+		StartDate: request.StartDate,
+		EndDate:   request.EndDate,
+		Plan:      plan,
+		Breakdown: b.Pricing.Price(plan, request.StartDate, request.EndDate),
+		Deposit:   b.Config.Deposits.For(vehicle.Type),
+	})
+	if err != nil {
+		return "", nil, err
+	}
+
+	// The quote keeps the price before the discount, which CreateBooking
+	// applies again when the promo code is sent along with the quote
+	if promotion != nil {
+		quote.Breakdown = b.discount(quote.Breakdown, *promotion)
+	}
+
+	return id, quote, nil
+}
+
+// vehicleAvailable reports whether vehicle is free between from and to, its
+// turnaround and maintenance windows included. It is the same check
+// CreateBooking makes under a lock, without the lock.
+func (b *BookingsService) vehicleAvailable(vehicle domain.Vehicle, from time.Time, to time.Time) (bool, error) {
+	buffer := b.Config.Turnaround.For(vehicle.Type)
+	conflicts, err := b.Database.GetOverlappingBookings(vehicle.ID, from.Add(-buffer), to.Add(buffer))
+	if err != nil {
+		return false, err
+	}
+
+	if len(conflicts) > 0 {
+		return false, nil
+	}
+
+	windows, err := b.Database.GetMaintenanceWindowsByVehicleID(vehicle.ID)
+	if err != nil {
+		return false, err
+	}
+
+	for _, window := range windows {
+		if window.StartDate.Before(to) && window.EndDate.After(from) {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+// CreateBooking reserves a vehicle. Bookings created from a quote keep the
+// quoted price and rates even if the vehicle rates changed since. A promo code is applied on
+// top of that price, and is only used up if the booking is created.
+func (b *BookingsService) CreateBooking(user domain.User, request requests.CreateBookingRequest) (*domain.Booking, error) {
 	vehicle, err := b.Database.GetVehicleById(request.VehicleID)
 	if err != nil {
 		return nil, err
@@ -52,32 +297,177 @@ func (b *BookingsService) CreateBooking(request requests.CreateBookingRequest) (
 		return nil, commons.ErrVehicleNotFound
 	}
 
+	if vehicle.Status != commons.VehicleStatusAvailable {
+		return nil, commons.ErrVehicleNotAvailable
+	}
+
+	plan := pricing.VehiclePlan(*vehicle)
+	if request.EndDate.Sub(request.StartDate) < plan.MinimumDuration {
+		return nil, commons.ErrBookingTooShort
+	}
+
+	breakdown := b.Pricing.Price(plan, request.StartDate, request.EndDate)
+	deposit := b.Config.Deposits.For(vehicle.Type)
+	if request.QuoteID != "" {
+		quote, err := b.Quotes.Verify(request.QuoteID)
+		if err != nil {
+			return nil, err
+		}
+
+		if quote.VehicleID != vehicle.ID || !quote.StartDate.Equal(request.StartDate) || !quote.EndDate.Equal(request.EndDate) {
+			return nil, commons.ErrQuoteMismatch
+		}
+
+		plan = quote.Plan
+		breakdown = quote.Breakdown
+		deposit = quote.Deposit
+	}
+
+	var promotion *domain.Promotion
+	if request.PromoCode != "" {
+		promotion, err = b.findPromotion(request.PromoCode, vehicle.Type, time.Now())
+		if err != nil {
+			return nil, err
+		}
+
+		breakdown = b.discount(breakdown, *promotion)
+	}
+
+	reason := "Booking reserved by " + actorName(&user)
 	booking := &domain.Booking{
 		Status:          commons.BookingStatusReserved,
-		UserID:          request.UserID,
-		User:            *user,
+		UserID:          user.ID,
+		User:            user,
 		VehicleID:       request.VehicleID,
 		Vehicle:         *vehicle,
 		StartDate:       request.StartDate,
 		EndDate:         request.EndDate,
 		PickUpLocation:  request.PickUpLocation,
 		DropOffLocation: request.DropOffLocation,
-		HourlyFare:      vehicle.HourlyFare,
+		HourlyFare:      plan.HourlyFare,
+		RatePlan:        plan.Rates(),
+		DepositAmount:   deposit,
+		Promotion:       promotion,
+		StatusChanges: []domain.BookingStatusChange{
+			{
+				ActorID:  &user.ID,
+				ToStatus: commons.BookingStatusReserved,
+				Reason:   reason,
+			},
+		},
+	}
+
+	if promotion != nil {
+		booking.PromotionID = &promotion.ID
 	}
 
-	return b.Database.CreateBooking(*booking)
+	applyBreakdown(booking, breakdown)
+
+	return b.Database.CreateBooking(*booking, b.Config.Turnaround)
 }
 
-func (b *BookingsService) CancelBooking(request requests.CancelBookingRequest) (*domain.Booking, error) {
-	user, err := b.Database.GetUserById(request.UserID)
+// RescheduleBooking moves a reserved or confirmed booking to new dates or to
+// another vehicle, keeping it only if the new slot is free. Switching vehicles
+// takes the fare and the deposit of the new one. Payments on hold that no
+// longer match the new price are authorized again, and the old holds are
+// released once the booking is moved.
+func (b *BookingsService) RescheduleBooking(user domain.User, request requests.RescheduleBookingRequest) (*domain.Booking, error) {
+	booking, err := b.Database.GetBookingById(request.ID)
 	if err != nil {
 		return nil, err
 	}
 
-	if user == nil {
-		return nil, commons.ErrInvalidCredentials
+	if booking == nil {
+		return nil, commons.ErrBookingNotFound
+	}
+
+	if !policy.Can(user, policy.RescheduleBooking, booking.UserID) {
+		return nil, commons.ErrForbidden
 	}
 
+	if booking.Status != commons.BookingStatusReserved && booking.Status != commons.BookingStatusConfirmed {
+		return nil, commons.ErrBookingNotReschedulable
+	}
+
+	if request.VehicleID != 0 && request.VehicleID != booking.VehicleID {
+		vehicle, err := b.Database.GetVehicleById(request.VehicleID)
+		if err != nil {
+			return nil, err
+		}
+
+		if vehicle == nil {
+			return nil, commons.ErrVehicleNotFound
+		}
+
+		booking.VehicleID = vehicle.ID
+		booking.Vehicle = *vehicle
+		booking.HourlyFare = vehicle.HourlyFare
+		booking.RatePlan = vehicle.RatePlan
+	}
+
+	if request.EndDate.Sub(request.StartDate) < pricing.BookingPlan(*booking).MinimumDuration {
+		return nil, commons.ErrBookingTooShort
+	}
+
+	reason := request.Reason
+	if reason == "" {
+		reason = fmt.Sprintf("Booking rescheduled from %s - %s to %s - %s by %s",
+			booking.StartDate.Format(time.RFC3339),
+			booking.EndDate.Format(time.RFC3339),
+			request.StartDate.Format(time.RFC3339),
+			request.EndDate.Format(time.RFC3339),
+			actorName(&user))
+	}
+
+	booking.StatusChanges = append(booking.StatusChanges, domain.BookingStatusChange{
+		BookingID:  booking.ID,
+		ActorID:    &user.ID,
+		FromStatus: booking.Status,
+		ToStatus:   booking.Status,
+		Reason:     reason,
+	})
+	booking.StartDate = request.StartDate
+	booking.EndDate = request.EndDate
+	booking.DepositAmount = b.Config.Deposits.For(booking.Vehicle.Type)
+	b.priceBooking(booking, booking.StartDate, booking.EndDate)
+
+	stale := staleHolds(*booking)
+	var holds []domain.Payment
+	if len(stale) > 0 {
+		if holds, err = b.authorizeHolds(*booking); err != nil {
+			return nil, err
+		}
+	}
+
+	rescheduled, err := b.Database.RescheduleBooking(*booking, b.Config.Turnaround, holds...)
+	if err != nil {
+		b.releaseHolds(*booking, holds)
+		return nil, err
+	}
+
+	if len(stale) == 0 {
+		return rescheduled, nil
+	}
+
+	released := make([]domain.Payment, 0, len(stale))
+	for i := range rescheduled.Payments {
+		payment := &rescheduled.Payments[i]
+		for _, hold := range stale {
+			if payment.ID == hold.ID {
+				b.voidPayment(*rescheduled, payment)
+				released = append(released, *payment)
+			}
+		}
+	}
+
+	return b.Database.SaveBookingPayments(*rescheduled, released...)
+}
+
+// ExtendBooking asks to keep a rented vehicle until a later end date. Short
+// extensions, and those requested by staff who could review them, are
+// approved at once; the rest wait for review. Both are announced in the
+// booking message thread.
+func (b *BookingsService) ExtendBooking(user domain.User, request requests.ExtendBookingRequest) (*domain.Booking, error) {
 	booking, err := b.Database.GetBookingById(request.ID)
 	if err != nil {
 		return nil, err
@@ -87,47 +477,70 @@ func (b *BookingsService) CancelBooking(request requests.CancelBookingRequest) (
 		return nil, commons.ErrBookingNotFound
 	}
 
-	if booking.UserID != request.UserID && user.Type != commons.UserTypeAdmin {
-		return nil, commons.ErrInvalidCredentials
+	if !policy.Can(user, policy.ExtendBooking, booking.UserID) {
+		return nil, commons.ErrForbidden
 	}
 
-	if booking.Status == commons.BookingStatusCancelled {
-		return nil, commons.ErrBookingAlreadyCancelled
+	if booking.Status != commons.BookingStatusInProgress {
+		return nil, commons.ErrBookingNotExtendable
 	}
 
-	if booking.Status == commons.BookingStatusFinished {
-		return nil, commons.ErrBookingAlreadyFinished
+	if !request.EndDate.After(booking.EndDate) {
+		return nil, commons.ErrInvalidExtension
 	}
 
-	if booking.Status == commons.BookingStatusConfirmed {
-		return nil, commons.ErrBookingAlreadyStarted
+	for _, extension := range booking.Extensions {
+		if extension.Status == commons.ExtensionStatusPending {
+			return nil, commons.ErrExtensionAlreadyPending
+		}
 	}
 
-	if booking.Status == commons.BookingStatusReserved {
-		booking.Status = commons.BookingStatusCancelled
+	// The booking itself is still rented out, so it shows up in its own
+	// overlap check and is skipped below
+	turnaround := b.Config.Turnaround.For(booking.Vehicle.Type)
+	conflicts, err := b.Database.GetOverlappingBookings(booking.VehicleID, booking.EndDate, request.EndDate.Add(turnaround))
+	if err != nil {
+		return nil, err
 	}
 
-	var message string
-	if user.Type == commons.UserTypeAdmin {
-		message = "Booking cancelled by admin"
-	} else {
-		message = "Booking cancelled by user"
+	for _, conflict := range conflicts {
+		if conflict.ID != booking.ID {
+			return nil, commons.ErrVehicleNotAvailable
+		}
 	}
-	booking.Observations = &message
 
-	return b.Database.UpdateBooking(*booking)
-}
+	extension := domain.BookingExtension{
+		BookingID:        booking.ID,
+		Status:           commons.ExtensionStatusPending,
+		RequestedByID:    user.ID,
+		PreviousEndDate:  booking.EndDate,
+		RequestedEndDate: request.EndDate,
+		ExtraCost:        b.extensionCost(*booking, request.EndDate),
+	}
 
-func (b *BookingsService) ConfirmBooking(request requests.ConfirmBookingRequest) (*domain.Booking, error) {
-	user, err := b.Database.GetUserById(request.UserID)
-	if err != nil {
-		return nil, err
+	message := fmt.Sprintf("Extension until %s requested by %s for %.2f",
+		request.EndDate.Format(time.RFC3339),
+		actorName(&user),
+		extension.ExtraCost)
+	if request.Message != "" {
+		message += ": " + request.Message
 	}
+	booking.Messages = append(booking.Messages, domain.BookingMessage{
+		BookingID: booking.ID,
+		Message:   message,
+	})
 
-	if user == nil {
-		return nil, commons.ErrInvalidCredentials
+	if request.EndDate.Sub(booking.EndDate) <= b.Config.MaxAutoExtension || policy.Has(user, policy.BookingsReviewExtensionAny) {
+		approveExtension(booking, &extension, nil, "")
+		b.priceBooking(booking, booking.StartDate, booking.EndDate)
 	}
 
+	return b.Database.SaveBookingExtension(*booking, extension, b.Config.Turnaround)
+}
+
+// ReviewExtension approves or rejects a pending extension, replying in the
+// booking message thread.
+func (b *BookingsService) ReviewExtension(user domain.User, request requests.ReviewExtensionRequest) (*domain.Booking, error) {
 	booking, err := b.Database.GetBookingById(request.ID)
 	if err != nil {
 		return nil, err
@@ -137,47 +550,141 @@ func (b *BookingsService) ConfirmBooking(request requests.ConfirmBookingRequest)
 		return nil, commons.ErrBookingNotFound
 	}
 
-	if booking.UserID != request.UserID && user.Type != commons.UserTypeAdmin {
-		return nil, commons.ErrInvalidCredentials
+	var extension *domain.BookingExtension
+	for i := range booking.Extensions {
+		if booking.Extensions[i].ID == request.ExtensionID {
+			extension = &booking.Extensions[i]
+		}
 	}
 
-	if booking.Status == commons.BookingStatusCancelled {
-		return nil, commons.ErrBookingAlreadyCancelled
+	if extension == nil {
+		return nil, commons.ErrExtensionNotFound
 	}
 
-	if booking.Status == commons.BookingStatusFinished {
-		return nil, commons.ErrBookingAlreadyFinished
+	if extension.Status != commons.ExtensionStatusPending {
+		return nil, commons.ErrExtensionNotPending
 	}
 
-	if booking.Status == commons.BookingStatusConfirmed {
-		return nil, commons.ErrBookingAlreadyStarted
+	if !*request.Approved {
+		extension.Status = commons.ExtensionStatusRejected
+		extension.ReviewedByID = &user.ID
+
+		message := "Extension rejected by " + actorName(&user)
+		if request.Message != "" {
+			message += ": " + request.Message
+		}
+		booking.Messages = append(booking.Messages, domain.BookingMessage{
+			BookingID: booking.ID,
+			Message:   message,
+		})
+
+		return b.Database.SaveBookingExtension(*booking, *extension, b.Config.Turnaround)
 	}
 
-	if booking.Status == commons.BookingStatusReserved {
-		booking.Status = commons.BookingStatusConfirmed
+	if booking.Status != commons.BookingStatusInProgress {
+		return nil, commons.ErrBookingNotExtendable
 	}
 
-	var message string
-	if user.Type == commons.UserTypeAdmin {
-		message = "Booking confirmed by admin"
-	} else {
-		message = "Booking confirmed by user"
+	approveExtension(booking, extension, &user, request.Message)
+	b.priceBooking(booking, booking.StartDate, booking.EndDate)
+
+	return b.Database.SaveBookingExtension(*booking, *extension, b.Config.Turnaround)
+}
+
+// approveExtension moves the end date of booking to the one requested by
+// extension and records it in the history and message thread. A nil reviewer
+// means the extension was approved automatically.
+func approveExtension(booking *domain.Booking, extension *domain.BookingExtension, reviewer *domain.User, note string) {
+	message := "Extension approved automatically"
+	var reviewerID *uint
+	if reviewer != nil {
+		message = "Extension approved by " + actorName(reviewer)
+		reviewerID = &reviewer.ID
+	}
+	if note != "" {
+		message += ": " + note
 	}
-	booking.Observations = &message
 
-	return b.Database.UpdateBooking(*booking)
+	extension.Status = commons.ExtensionStatusApproved
+	extension.ReviewedByID = reviewerID
+
+	booking.StatusChanges = append(booking.StatusChanges, domain.BookingStatusChange{
+		BookingID:  booking.ID,
+		ActorID:    reviewerID,
+		FromStatus: booking.Status,
+		ToStatus:   booking.Status,
+		Reason:     "Booking extended until " + extension.RequestedEndDate.Format(time.RFC3339),
+	})
+	booking.Messages = append(booking.Messages, domain.BookingMessage{
+		BookingID: booking.ID,
+		Message:   message,
+	})
+	booking.EndDate = extension.RequestedEndDate
+}
+
+// CancelBooking cancels a reserved or confirmed booking. The fee of the
+// cancellation policy is collected and everything else paid is given back.
+func (b *BookingsService) CancelBooking(user domain.User, request requests.CancelBookingRequest) (*domain.Booking, error) {
+	booking, err := b.Database.GetBookingById(request.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if booking == nil {
+		return nil, commons.ErrBookingNotFound
+	}
+
+	now := time.Now()
+	fromStatus := booking.Status
+	if err := applyBookingEvent(booking, BookingEventCancel, &user, request.Reason, now); err != nil {
+		return nil, err
+	}
+
+	booking.CancellationFee = b.Config.Cancellation.Fee(*booking, now)
+	var changed []domain.Payment
+	if len(booking.Payments) > 0 {
+		changed = b.settleCancellation(booking)
+	}
+
+	return b.Database.SaveBookingTransition(*booking, fromStatus, changed...)
 }
 
-func (b *BookingsService) FinishBooking(request requests.FinishBookingRequest) (*domain.Booking, error) {
-	user, err := b.Database.GetUserById(request.UserID)
+// ConfirmBooking confirms a reservation, putting its total and its deposit on
+// hold with the payment provider. Both are settled when the vehicle is
+// returned.
+func (b *BookingsService) ConfirmBooking(user domain.User, request requests.ConfirmBookingRequest) (*domain.Booking, error) {
+	booking, err := b.Database.GetBookingById(request.ID)
 	if err != nil {
 		return nil, err
 	}
 
-	if user == nil {
-		return nil, commons.ErrInvalidCredentials
+	if booking == nil {
+		return nil, commons.ErrBookingNotFound
+	}
+
+	fromStatus := booking.Status
+	if err := applyBookingEvent(booking, BookingEventConfirm, &user, request.Reason, time.Now()); err != nil {
+		return nil, err
+	}
+
+	holds, err := b.authorizeHolds(*booking)
+	if err != nil {
+		return nil, err
 	}
 
+	confirmed, err := b.Database.SaveBookingTransition(*booking, fromStatus, holds...)
+	if err != nil {
+		// Do not keep money on hold for a booking that was not confirmed
+		b.releaseHolds(*booking, holds)
+		return nil, err
+	}
+
+	return confirmed, nil
+}
+
+// CheckOutBooking hands the vehicle over to the customer, recording the actual
+// pick up time and the state of the vehicle at that moment.
+func (b *BookingsService) CheckOutBooking(user domain.User, request requests.CheckOutBookingRequest) (*domain.Booking, error) {
 	booking, err := b.Database.GetBookingById(request.ID)
 	if err != nil {
 		return nil, err
@@ -187,47 +694,84 @@ func (b *BookingsService) FinishBooking(request requests.FinishBookingRequest) (
 		return nil, commons.ErrBookingNotFound
 	}
 
-	if booking.UserID != request.UserID && user.Type != commons.UserTypeAdmin {
-		return nil, commons.ErrInvalidCredentials
+	now := time.Now()
+	fromStatus := booking.Status
+	if err := applyBookingEvent(booking, BookingEventPickUp, &user, request.Reason, now); err != nil {
+		return nil, err
 	}
 
-	if booking.Status == commons.BookingStatusCancelled {
-		return nil, commons.ErrBookingAlreadyCancelled
+	booking.ActualPickUpAt = &now
+	booking.PickUpOdometer = request.Odometer
+	booking.PickUpFuelLevel = request.FuelLevel
+
+	return b.Database.SaveBookingTransition(*booking, fromStatus)
+}
+
+// FinishBooking checks the vehicle back in, recording the actual return time
+// and the state of the vehicle.
+func (b *BookingsService) FinishBooking(user domain.User, request requests.FinishBookingRequest) (*domain.Booking, error) {
+	booking, err := b.Database.GetBookingById(request.ID)
+	if err != nil {
+		return nil, err
 	}
 
-	if booking.Status == commons.BookingStatusFinished {
-		return nil, commons.ErrBookingAlreadyFinished
+	if booking == nil {
+		return nil, commons.ErrBookingNotFound
 	}
 
-	if booking.Status == commons.BookingStatusReserved {
-		return nil, commons.ErrBookingNotStarted
+	now := time.Now()
+	fromStatus := booking.Status
+	if err := applyBookingEvent(booking, BookingEventFinish, &user, request.Reason, now); err != nil {
+		return nil, err
 	}
 
-	if booking.Status == commons.BookingStatusConfirmed {
-		booking.Status = commons.BookingStatusFinished
+	if booking.PickUpOdometer != nil && *request.Odometer < *booking.PickUpOdometer {
+		return nil, commons.ErrInvalidOdometer
 	}
 
-	var message string
-	if user.Type == commons.UserTypeAdmin {
-		message = "Booking finished by admin"
-	} else {
-		message = "Booking finished by user"
+	booking.ActualReturnAt = &now
+	booking.ReturnOdometer = request.Odometer
+	booking.ReturnFuelLevel = request.FuelLevel
+
+	// The rental is billed for the time the customer actually had the vehicle
+	if booking.ActualPickUpAt != nil {
+		b.priceBooking(booking, *booking.ActualPickUpAt, now)
 	}
-	booking.Observations = &message
 
-	return b.Database.UpdateBooking(*booking)
+	changed := b.capturePayments(booking)
+	changed = append(changed, b.settleDeposit(booking, now)...)
+
+	return b.Database.SaveBookingTransition(*booking, fromStatus, changed...)
 }
 
-func (b *BookingsService) AddFeedbackBooking(request requests.AddFeedbackBookingRequest) (*domain.Booking, error) {
-	user, err := b.Database.GetUserById(request.UserID)
+// ChargeBooking posts a charge against the deposit of a rental, to be taken
+// from it when the vehicle is returned.
+func (b *BookingsService) ChargeBooking(user domain.User, request requests.ChargeBookingRequest) (*domain.Booking, error) {
+	booking, err := b.Database.GetBookingById(request.ID)
 	if err != nil {
 		return nil, err
 	}
 
-	if user == nil {
-		return nil, commons.ErrInvalidCredentials
+	if booking == nil {
+		return nil, commons.ErrBookingNotFound
 	}
 
+	if booking.Status != commons.BookingStatusInProgress && booking.Status != commons.BookingStatusOverdue {
+		return nil, commons.ErrBookingNotChargeable
+	}
+
+	booking.Charges = append(booking.Charges, domain.BookingCharge{
+		BookingID:   booking.ID,
+		Kind:        request.Kind,
+		Description: request.Description,
+		Amount:      roundCents(request.Amount),
+		PostedByID:  user.ID,
+	})
+
+	return b.Database.UpdateBooking(*booking)
+}
+
+func (b *BookingsService) AddFeedbackBooking(user domain.User, request requests.AddFeedbackBookingRequest) (*domain.Booking, error) {
 	booking, err := b.Database.GetBookingById(request.ID)
 	if err != nil {
 		return nil, err
@@ -237,8 +781,8 @@ func (b *BookingsService) AddFeedbackBooking(request requests.AddFeedbackBooking
 		return nil, commons.ErrBookingNotFound
 	}
 
-	if booking.UserID != request.UserID || user.Type != commons.UserTypeClient {
-		return nil, commons.ErrInvalidCredentials
+	if !policy.Can(user, policy.FeedbackBooking, booking.UserID) {
+		return nil, commons.ErrForbidden
 	}
 
 	if booking.Status == commons.BookingStatusReserved {
@@ -266,16 +810,7 @@ func (b *BookingsService) AddFeedbackBooking(request requests.AddFeedbackBooking
 	return b.Database.UpdateBooking(*booking)
 }
 
-func (b *BookingsService) RateBooking(request requests.RateBookingRequest) (*domain.Booking, error) {
-	user, err := b.Database.GetUserById(request.UserID)
-	if err != nil {
-		return nil, err
-	}
-
-	if user == nil {
-		return nil, commons.ErrInvalidCredentials
-	}
-
+func (b *BookingsService) RateBooking(user domain.User, request requests.RateBookingRequest) (*domain.Booking, error) {
 	booking, err := b.Database.GetBookingById(request.ID)
 	if err != nil {
 		return nil, err
@@ -285,8 +820,8 @@ func (b *BookingsService) RateBooking(request requests.RateBookingRequest) (*dom
 		return nil, commons.ErrBookingNotFound
 	}
 
-	if booking.UserID != request.UserID || user.Type != commons.UserTypeClient {
-		return nil, commons.ErrInvalidCredentials
+	if !policy.Can(user, policy.RateBooking, booking.UserID) {
+		return nil, commons.ErrForbidden
 	}
 
 	if booking.Status == commons.BookingStatusReserved {
@@ -310,16 +845,7 @@ func (b *BookingsService) RateBooking(request requests.RateBookingRequest) (*dom
 	return b.Database.UpdateBooking(*booking)
 }
 
-func (b *BookingsService) AddMessageToBooking(request requests.AddMessageToBookingRequest) (*domain.Booking, error) {
-	user, err := b.Database.GetUserById(request.UserID)
-	if err != nil {
-		return nil, err
-	}
-
-	if user == nil {
-		return nil, commons.ErrInvalidCredentials
-	}
-
+func (b *BookingsService) AddMessageToBooking(user domain.User, request requests.AddMessageToBookingRequest) (*domain.Booking, error) {
 	booking, err := b.Database.GetBookingById(request.ID)
 	if err != nil {
 		return nil, err
@@ -329,8 +855,8 @@ func (b *BookingsService) AddMessageToBooking(request requests.AddMessageToBooki
 		return nil, commons.ErrBookingNotFound
 	}
 
-	if booking.UserID != request.UserID || user.Type != commons.UserTypeClient {
-		return nil, commons.ErrInvalidCredentials
+	if !policy.Can(user, policy.MessageBooking, booking.UserID) {
+		return nil, commons.ErrForbidden
 	}
 
 	message := domain.BookingMessage{
@@ -342,11 +868,21 @@ func (b *BookingsService) AddMessageToBooking(request requests.AddMessageToBooki
 	return b.Database.UpdateBooking(*booking)
 }
 
-func (b *BookingsService) GetBookingsByUserID(userID uint) ([]domain.Booking, error) {
-	return b.Database.GetBookingsByUserID(userID)
+// GetBookings lists the bookings of one user, the authenticated one unless
+// another user id is requested.
+func (b *BookingsService) GetBookings(user domain.User, request requests.ListBookingsRequest) ([]domain.Booking, int64, error) {
+	if request.UserID == 0 {
+		request.UserID = user.ID
+	}
+
+	if !policy.Can(user, policy.ReadBooking, request.UserID) {
+		return nil, 0, commons.ErrForbidden
+	}
+
+	return b.Database.GetBookings(mapListBookingsRequestToFilter(request))
 }
 
-func (b *BookingsService) GetBookingByID(bookingID uint) (*domain.Booking, error) {
+func (b *BookingsService) GetBookingByID(user domain.User, bookingID uint) (*domain.Booking, error) {
 	booking, err := b.Database.GetBookingById(bookingID)
 	if err != nil {
 		return nil, err
@@ -356,9 +892,235 @@ func (b *BookingsService) GetBookingByID(bookingID uint) (*domain.Booking, error
 		return nil, commons.ErrBookingNotFound
 	}
 
+	if !policy.Can(user, policy.ReadBooking, booking.UserID) {
+		return nil, commons.ErrForbidden
+	}
+
 	return booking, nil
 }
 
-func (b *BookingsService) GetAdminBookings() ([]domain.Booking, error) {
-	return b.Database.GetAdminBookings()
+func (b *BookingsService) GetAdminBookings(request requests.ListBookingsRequest) ([]domain.Booking, int64, error) {
+	return b.Database.GetBookings(mapListBookingsRequestToFilter(request))
+}
+
+// ExpireReservations expires the reservations that were not confirmed within
+// the hold window or whose start date already passed. It returns how many
+// bookings were expired.
+func (b *BookingsService) ExpireReservations(now time.Time) (int, error) {
+	bookings, err := b.Database.GetExpiredReservations(now.Add(-b.Config.ReservationHold), now)
+	if err != nil {
+		return 0, err
+	}
+
+	expired := 0
+	for _, booking := range bookings {
+		reason := fmt.Sprintf("Reservation not confirmed within %s", b.Config.ReservationHold)
+		if !booking.StartDate.After(now) {
+			reason = "Reservation not confirmed before its start date"
+		}
+
+		if err := applyBookingEvent(&booking, BookingEventExpire, nil, reason, now); err != nil {
+			return expired, err
+		}
+
+		// The booking may have been confirmed since it was loaded
+		ok, err := b.Database.TransitionBooking(booking, commons.BookingStatusReserved)
+		if err != nil {
+			return expired, err
+		}
+
+		if ok {
+			expired++
+		}
+	}
+
+	return expired, nil
+}
+
+// FlagNoShowsAndOverdue flags confirmed bookings that were never picked up
+// and rentals that were not returned on time, notifying admins of each one.
+func (b *BookingsService) FlagNoShowsAndOverdue(now time.Time) error {
+	noShows, err := b.Database.GetConfirmedBookingsStartedBefore(now.Add(-b.Config.NoShowGrace))
+	if err != nil {
+		return err
+	}
+
+	for _, booking := range noShows {
+		reason := "Vehicle not picked up by " + booking.StartDate.Add(b.Config.NoShowGrace).Format(time.RFC3339)
+		if err := b.flagBooking(booking, BookingEventNoShow, reason, now); err != nil {
+			return err
+		}
+	}
+
+	overdue, err := b.Database.GetInProgressBookingsEndedBefore(now.Add(-b.Config.OverdueGrace))
+	if err != nil {
+		return err
+	}
+
+	for _, booking := range overdue {
+		reason := "Vehicle not returned by " + booking.EndDate.Add(b.Config.OverdueGrace).Format(time.RFC3339)
+		if err := b.flagBooking(booking, BookingEventOverdue, reason, now); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// SyncRefunds asks the payment provider how pending refunds are going and
+// records the ones it settled.
+func (b *BookingsService) SyncRefunds(now time.Time) error {
+	refunds, err := b.Database.GetPendingRefunds()
+	if err != nil {
+		return err
+	}
+
+	// A refund that cannot be checked must not hold back the ones after it
+	var errs []error
+	for _, refund := range refunds {
+		transaction, err := b.Payments.RefundStatus(refund.ProviderReference)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("refund %d: %w", refund.ID, err))
+			continue
+		}
+
+		if transaction.Status == refund.Status {
+			continue
+		}
+
+		if err := b.updateRefund(refund, transaction.Status, now); err != nil {
+			errs = append(errs, fmt.Errorf("refund %d: %w", refund.ID, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// updateRefund records the status the provider settled refund with. Admins
+// are told about failed refunds, which have to be given back by hand.
+func (b *BookingsService) updateRefund(refund domain.Refund, status string, now time.Time) error {
+	refund.Status = status
+	if status == commons.RefundStatusCompleted {
+		refund.CompletedAt = &now
+	}
+
+	if _, err := b.Database.UpdateRefund(refund); err != nil {
+		return err
+	}
+
+	if status != commons.RefundStatusFailed {
+		return nil
+	}
+
+	subject := fmt.Sprintf("Refund %d failed for booking %d", refund.ID, refund.BookingID)
+	return b.Notifier.NotifyAdmins(subject, fmt.Sprintf("%.2f could not be refunded", refund.Amount))
+}
+
+// flagBooking applies event to booking unless it changed status since it was
+// loaded, e.g. because the vehicle was picked up or returned meanwhile. The
+// no show fee is only settled once the booking is flagged.
+func (b *BookingsService) flagBooking(booking domain.Booking, event string, reason string, now time.Time) error {
+	fromStatus := booking.Status
+	if err := applyBookingEvent(&booking, event, nil, reason, now); err != nil {
+		return err
+	}
+
+	ok, err := b.Database.TransitionBooking(booking, fromStatus)
+	if err != nil {
+		return err
+	}
+
+	if !ok {
+		return nil
+	}
+
+	if event == BookingEventNoShow {
+		flagged, err := b.Database.GetBookingById(booking.ID)
+		if err != nil {
+			return err
+		}
+
+		flagged.CancellationFee = b.Config.Cancellation.NoShow(*flagged)
+		if _, err := b.Database.SaveBookingPayments(*flagged, b.settleCancellation(flagged)...); err != nil {
+			return err
+		}
+	}
+
+	subject := fmt.Sprintf("Booking %d %s", booking.ID, bookingTransitions[event].Verb)
+	return b.Notifier.NotifyAdmins(subject, reason)
+}
+
+// priceBooking prices booking between from and to with the rate plan and the
+// promotion it was booked with, replacing its line items.
+func (b *BookingsService) priceBooking(booking *domain.Booking, from time.Time, to time.Time) {
+	applyBreakdown(booking, b.price(*booking, from, to))
+}
+
+func (b *BookingsService) price(booking domain.Booking, from time.Time, to time.Time) pricing.Breakdown {
+	breakdown := b.Pricing.Price(pricing.BookingPlan(booking), from, to)
+	if booking.Promotion != nil {
+		breakdown = b.discount(breakdown, *booking.Promotion)
+	}
+
+	return breakdown
+}
+
+func (b *BookingsService) discount(breakdown pricing.Breakdown, promotion domain.Promotion) pricing.Breakdown {
+	description := "Promo code " + promotion.Code
+	return b.Pricing.Discount(breakdown, description, promotionDiscount(promotion, breakdown.Subtotal))
+}
+
+// findPromotion looks up the promotion of code, checking it can be used at now
+// on a vehicle of vehicleType.
+func (b *BookingsService) findPromotion(code string, vehicleType string, now time.Time) (*domain.Promotion, error) {
+	promotion, err := b.Database.GetPromotionByCode(normalizePromoCode(code))
+	if err != nil {
+		return nil, err
+	}
+
+	if promotion == nil || promotion.DeletedAt.Valid || !promotionApplies(*promotion, vehicleType, now) {
+		return nil, commons.ErrInvalidPromoCode
+	}
+
+	return promotion, nil
+}
+
+// applyBreakdown sets the totals and line items of booking to breakdown.
+func applyBreakdown(booking *domain.Booking, breakdown pricing.Breakdown) {
+	booking.TotalAmount = breakdown.Total
+	booking.DiscountAmount = breakdown.Discount
+	booking.TaxAmount = breakdown.Tax
+	booking.LineItems = make([]domain.BookingLineItem, 0, len(breakdown.Lines))
+	for _, line := range breakdown.Lines {
+		booking.LineItems = append(booking.LineItems, domain.BookingLineItem{
+			BookingID:   booking.ID,
+			Description: line.Description,
+			Quantity:    line.Quantity,
+			UnitPrice:   line.UnitPrice,
+			Amount:      line.Amount,
+		})
+	}
+}
+
+// extensionCost is how much more booking costs if it ends at endDate instead.
+func (b *BookingsService) extensionCost(booking domain.Booking, endDate time.Time) float64 {
+	extended := b.price(booking, booking.StartDate, endDate)
+	current := b.price(booking, booking.StartDate, booking.EndDate)
+
+	return extended.Total - current.Total
+}
+
+func mapListBookingsRequestToFilter(request requests.ListBookingsRequest) BookingFilter {
+	return BookingFilter{
+		UserID:    request.UserID,
+		Status:    request.Status,
+		VehicleID: request.VehicleID,
+		From:      request.From,
+		To:        request.To,
+		Overdue:   request.Overdue,
+		Now:       time.Now(),
+		Sort:      request.Sort,
+		Offset:    request.Offset(),
+		Limit:     request.Limit(),
+	}
 }
@@ -0,0 +1,133 @@
+package services
+
+import (
+	"fmt"
+	"time"
+
+	"backend/src/commons"
+	"backend/src/domain"
+	"backend/src/payments"
+)
+
+// HandlePaymentEvent applies an event sent by the gateway of provider to the
+// payment or refund it is about. The event is claimed before it is applied,
+// in the same transaction, so gateways can safely deliver it again, even
+// concurrently. It reports whether the event was new.
+func (b *BookingsService) HandlePaymentEvent(provider string, event payments.Event, now time.Time) (bool, error) {
+	// Events of unknown types are recorded too, so they are not retried
+	record := domain.WebhookEvent{
+		Provider:      provider,
+		EventID:       event.ID,
+		Type:          event.Type,
+		TransactionID: event.TransactionID,
+	}
+
+	return b.Database.ClaimWebhookEvent(record, func(database BookingsDatabase) error {
+		claimed := *b
+		claimed.Database = database
+
+		switch event.Type {
+		case payments.EventPaymentAuthorized, payments.EventPaymentCaptured, payments.EventPaymentVoided, payments.EventPaymentFailed:
+			return claimed.applyPaymentEvent(provider, event, now)
+		case payments.EventRefundCompleted, payments.EventRefundFailed:
+			return claimed.applyRefundEvent(provider, event, now)
+		}
+
+		return nil
+	})
+}
+
+func (b *BookingsService) applyPaymentEvent(provider string, event payments.Event, now time.Time) error {
+	payment, err := b.Database.GetPaymentByReference(provider, event.TransactionID)
+	if err != nil {
+		return err
+	}
+
+	if payment == nil {
+		return commons.ErrPaymentNotFound
+	}
+
+	// Only payments the gateway has not settled yet can change
+	if payment.Status != commons.PaymentStatusPending && payment.Status != commons.PaymentStatusAuthorized {
+		return nil
+	}
+
+	switch event.Type {
+	case payments.EventPaymentAuthorized:
+		payment.Status = commons.PaymentStatusAuthorized
+	case payments.EventPaymentCaptured:
+		payment.Status = commons.PaymentStatusCaptured
+		payment.CapturedAmount = payment.Amount
+		if event.Amount > 0 {
+			payment.CapturedAmount = roundCents(min(event.Amount, payment.Amount))
+		}
+	case payments.EventPaymentVoided:
+		payment.Status = commons.PaymentStatusVoided
+	case payments.EventPaymentFailed:
+		return b.failPayment(*payment, now)
+	}
+
+	_, err = b.Database.UpdatePayment(*payment)
+	return err
+}
+
+// failPayment records that the gateway could not take a payment. Bookings not
+// picked up yet lose their confirmation, since nothing guarantees them
+// anymore, and whatever else they hold is released. Admins are told either
+// way.
+func (b *BookingsService) failPayment(payment domain.Payment, now time.Time) error {
+	booking, err := b.Database.GetBookingById(payment.BookingID)
+	if err != nil {
+		return err
+	}
+
+	if booking == nil {
+		return commons.ErrBookingNotFound
+	}
+
+	for i := range booking.Payments {
+		if booking.Payments[i].ID == payment.ID {
+			booking.Payments[i].Status = commons.PaymentStatusFailed
+		}
+	}
+	payment.Status = commons.PaymentStatusFailed
+
+	reason := fmt.Sprintf("Payment %d was declined by the payment provider", payment.ID)
+	changed := []domain.Payment{payment}
+	if booking.Status == commons.BookingStatusReserved || booking.Status == commons.BookingStatusConfirmed {
+		if err := applyBookingEvent(booking, BookingEventCancel, nil, reason, now); err != nil {
+			return err
+		}
+
+		changed = append(changed, b.settleCancellation(booking)...)
+	}
+
+	if _, err := b.Database.SaveBookingPayments(*booking, changed...); err != nil {
+		return err
+	}
+
+	subject := fmt.Sprintf("Payment failed for booking %d", booking.ID)
+	return b.Notifier.NotifyAdmins(subject, reason)
+}
+
+func (b *BookingsService) applyRefundEvent(provider string, event payments.Event, now time.Time) error {
+	refund, err := b.Database.GetRefundByReference(provider, event.TransactionID)
+	if err != nil {
+		return err
+	}
+
+	if refund == nil {
+		return commons.ErrRefundNotFound
+	}
+
+	if refund.Status != commons.RefundStatusPending {
+		return nil
+	}
+
+	return b.updateRefund(*refund, refundEventStatuses[event.Type], now)
+}
+
+var refundEventStatuses = map[string]string{
+	payments.EventRefundCompleted: commons.RefundStatusCompleted,
+	payments.EventRefundFailed:    commons.RefundStatusFailed,
+}
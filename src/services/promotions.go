@@ -0,0 +1,147 @@
+package services
+
+import (
+	"math"
+	"slices"
+	"strings"
+	"time"
+
+	"backend/src/commons"
+	"backend/src/domain"
+	"backend/src/handlers/requests"
+)
+
+type PromotionsDatabase interface {
+	GetPromotions() ([]domain.Promotion, error)
+	GetPromotionById(id uint) (*domain.Promotion, error)
+	GetPromotionByCode(code string) (*domain.Promotion, error)
+	CreatePromotion(promotion domain.Promotion) (*domain.Promotion, error)
+	UpdatePromotion(promotion domain.Promotion) (*domain.Promotion, error)
+	DeletePromotion(id uint) error
+}
+
+type PromotionsService struct {
+	Database PromotionsDatabase
+}
+
+func NewPromotionsService(database PromotionsDatabase) *PromotionsService {
+	return &PromotionsService{
+		Database: database,
+	}
+}
+
+func (p *PromotionsService) GetPromotions() ([]domain.Promotion, error) {
+	return p.Database.GetPromotions()
+}
+
+func (p *PromotionsService) GetPromotionByID(promotionID uint) (*domain.Promotion, error) {
+	promotion, err := p.Database.GetPromotionById(promotionID)
+	if err != nil {
+		return nil, err
+	}
+
+	if promotion == nil {
+		return nil, commons.ErrPromotionNotFound
+	}
+
+	return promotion, nil
+}
+
+func (p *PromotionsService) CreatePromotion(request requests.CreatePromotionRequest) (*domain.Promotion, error) {
+	existing, err := p.Database.GetPromotionByCode(normalizePromoCode(request.Code))
+	if err != nil {
+		return nil, err
+	}
+
+	if existing != nil {
+		return nil, commons.ErrPromotionAlreadyExists
+	}
+
+	promotion := domain.Promotion{
+		Code:           normalizePromoCode(request.Code),
+		Description:    request.Description,
+		DiscountType:   request.DiscountType,
+		DiscountValue:  request.DiscountValue,
+		ValidFrom:      request.ValidFrom,
+		ValidUntil:     request.ValidUntil,
+		VehicleTypes:   request.VehicleTypes,
+		MaxUses:        request.MaxUses,
+		MaxUsesPerUser: request.MaxUsesPerUser,
+	}
+
+	return p.Database.CreatePromotion(promotion)
+}
+
+// UpdatePromotion changes the terms of a promotion. Bookings already made
+// with it are repriced with the new terms only if they are rescheduled.
+func (p *PromotionsService) UpdatePromotion(request requests.UpdatePromotionRequest) (*domain.Promotion, error) {
+	promotion, err := p.Database.GetPromotionById(request.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if promotion == nil {
+		return nil, commons.ErrPromotionNotFound
+	}
+
+	code := normalizePromoCode(request.Code)
+	if code != promotion.Code {
+		existing, err := p.Database.GetPromotionByCode(code)
+		if err != nil {
+			return nil, err
+		}
+
+		if existing != nil {
+			return nil, commons.ErrPromotionAlreadyExists
+		}
+	}
+
+	promotion.Code = code
+	promotion.Description = request.Description
+	promotion.DiscountType = request.DiscountType
+	promotion.DiscountValue = request.DiscountValue
+	promotion.ValidFrom = request.ValidFrom
+	promotion.ValidUntil = request.ValidUntil
+	promotion.VehicleTypes = request.VehicleTypes
+	promotion.MaxUses = request.MaxUses
+	promotion.MaxUsesPerUser = request.MaxUsesPerUser
+
+	return p.Database.UpdatePromotion(*promotion)
+}
+
+func (p *PromotionsService) DeletePromotion(promotionID uint) error {
+	promotion, err := p.Database.GetPromotionById(promotionID)
+	if err != nil {
+		return err
+	}
+
+	if promotion == nil {
+		return commons.ErrPromotionNotFound
+	}
+
+	return p.Database.DeletePromotion(promotion.ID)
+}
+
+// normalizePromoCode makes codes case insensitive.
+func normalizePromoCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// promotionApplies reports whether promotion can be used at now to rent a
+// vehicle of vehicleType. Usage limits are checked when the booking is stored.
+func promotionApplies(promotion domain.Promotion, vehicleType string, now time.Time) bool {
+	if now.Before(promotion.ValidFrom) || now.After(promotion.ValidUntil) {
+		return false
+	}
+
+	return len(promotion.VehicleTypes) == 0 || slices.Contains(promotion.VehicleTypes, vehicleType)
+}
+
+// promotionDiscount is how much promotion takes off subtotal.
+func promotionDiscount(promotion domain.Promotion, subtotal float64) float64 {
+	if promotion.DiscountType == commons.DiscountTypePercentage {
+		return math.Round(subtotal*promotion.DiscountValue) / 100
+	}
+
+	return promotion.DiscountValue
+}
@@ -1,25 +1,42 @@
 package services
 
 import (
+	"errors"
+	"time"
+
+	"backend/src/auth"
 	"backend/src/commons"
 	"backend/src/domain"
 	"backend/src/handlers/requests"
 
 	"github.com/labstack/echo/v4"
+	"github.com/labstack/gommon/log"
 )
 
 type UsersDatabase interface {
+	GetUserById(id uint) (*domain.User, error)
 	GetUserByEmail(email string) (*domain.User, error)
 	CreateUser(user domain.User) (*domain.User, error)
+	UpdateUser(user domain.User) (*domain.User, error)
+	CreateSession(session domain.Session) (*domain.Session, error)
+	GetSessionById(id uint) (*domain.Session, error)
+	GetActiveSessionsByUserID(userID uint, now time.Time) ([]domain.Session, error)
+	GetRefreshTokenByHash(hash string) (*domain.RefreshToken, error)
+	RotateRefreshToken(current domain.RefreshToken, next domain.RefreshToken) (*domain.RefreshToken, error)
+	RevokeSession(id uint, revokedAt time.Time) error
 }
 
 type UsersService struct {
-	Database UsersDatabase
+	Database  UsersDatabase
+	Tokens    *auth.Tokens
+	Passwords *auth.Passwords
 }
 
-func NewUsersService(database UsersDatabase) *UsersService {
+func NewUsersService(database UsersDatabase, tokens *auth.Tokens, passwords *auth.Passwords) *UsersService {
 	return &UsersService{
-		Database: database,
+		Database:  database,
+		Tokens:    tokens,
+		Passwords: passwords,
 	}
 }
 
@@ -33,33 +50,187 @@ func (u *UsersService) RegisterUser(context echo.Context, request requests.Regis
 		return nil, commons.ErrUserAlreadyExists
 	}
 
-	user = mapRegisterUserRequestToUser(request)
+	hash, err := u.Passwords.Hash(request.Password)
+	if err != nil {
+		return nil, err
+	}
+
+	user = mapRegisterUserRequestToUser(request, hash)
 
 	return u.Database.CreateUser(*user)
 }
 
-func (u *UsersService) LoginUser(context echo.Context, request requests.LoginUserRequest) (*domain.User, error) {
+func (u *UsersService) LoginUser(context echo.Context, request requests.LoginUserRequest) (*domain.User, *auth.TokenPair, error) {
 	user, err := u.Database.GetUserByEmail(request.Email)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
+	}
+
+	if user == nil {
+		u.Passwords.VerifyMissing(request.Password)
+		return nil, nil, commons.ErrUserNotFound
+	}
+
+	valid, needsRehash := u.Passwords.Verify(user.Password, request.Password)
+	if !valid {
+		return nil, nil, commons.ErrInvalidCredentials
+	}
+
+	if needsRehash {
+		// Legacy passwords bcrypt cannot hash, e.g. longer than 72 bytes, are
+		// kept as they are rather than locking their owner out
+		if hash, err := u.Passwords.Hash(request.Password); err != nil {
+			log.Warnf("could not rehash password of user %d: %v", user.ID, err)
+		} else {
+			user.Password = hash
+			if user, err = u.Database.UpdateUser(*user); err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+
+	refreshToken, refreshTokenHash, err := auth.NewRefreshToken()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(u.Tokens.RefreshTokenTTL())
+	session, err := u.Database.CreateSession(domain.Session{
+		UserID:     user.ID,
+		UserAgent:  context.Request().UserAgent(),
+		IPAddress:  context.RealIP(),
+		LastUsedAt: now,
+		ExpiresAt:  expiresAt,
+		RefreshTokens: []domain.RefreshToken{
+			{
+				TokenHash: refreshTokenHash,
+				ExpiresAt: expiresAt,
+			},
+		},
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	tokens, err := u.issueTokenPair(*user, *session, refreshToken)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return user, tokens, nil
+}
+
+// RefreshToken exchanges a refresh token for a new token pair. Every refresh
+// token can be used only once; presenting one that was already rotated means
+// it leaked, so the whole session is revoked.
+func (u *UsersService) RefreshToken(context echo.Context, request requests.RefreshTokenRequest) (*domain.User, *auth.TokenPair, error) {
+	current, err := u.Database.GetRefreshTokenByHash(auth.HashRefreshToken(request.RefreshToken))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if current == nil {
+		return nil, nil, commons.ErrInvalidRefreshToken
+	}
+
+	now := time.Now()
+	session := current.Session
+	if session.RevokedAt != nil || session.ExpiresAt.Before(now) || current.ExpiresAt.Before(now) {
+		return nil, nil, commons.ErrInvalidRefreshToken
+	}
+
+	if current.RotatedAt != nil {
+		if err := u.Database.RevokeSession(session.ID, now); err != nil {
+			return nil, nil, err
+		}
+
+		return nil, nil, commons.ErrRefreshTokenReused
+	}
+
+	user, err := u.Database.GetUserById(session.UserID)
+	if err != nil {
+		return nil, nil, err
 	}
 
 	if user == nil {
-		return nil, commons.ErrUserNotFound
+		return nil, nil, commons.ErrInvalidRefreshToken
+	}
+
+	refreshToken, refreshTokenHash, err := auth.NewRefreshToken()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Sessions slide: every refresh extends them by a full refresh token TTL
+	next, err := u.Database.RotateRefreshToken(*current, domain.RefreshToken{
+		SessionID: session.ID,
+		TokenHash: refreshTokenHash,
+		ExpiresAt: now.Add(u.Tokens.RefreshTokenTTL()),
+	})
+	if err != nil {
+		if errors.Is(err, commons.ErrRefreshTokenReused) {
+			// Another request rotated the same token first
+			if revokeErr := u.Database.RevokeSession(session.ID, now); revokeErr != nil {
+				return nil, nil, revokeErr
+			}
+		}
+
+		return nil, nil, err
+	}
+
+	tokens, err := u.issueTokenPair(*user, next.Session, refreshToken)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return user, tokens, nil
+}
+
+func (u *UsersService) Logout(context echo.Context, user domain.User, session domain.Session) error {
+	return u.Database.RevokeSession(session.ID, time.Now())
+}
+
+func (u *UsersService) GetSessions(context echo.Context, user domain.User) ([]domain.Session, error) {
+	return u.Database.GetActiveSessionsByUserID(user.ID, time.Now())
+}
+
+func (u *UsersService) RevokeSession(context echo.Context, user domain.User, sessionID uint) error {
+	session, err := u.Database.GetSessionById(sessionID)
+	if err != nil {
+		return err
 	}
 
-	if user.Password != request.Password {
-		return nil, commons.ErrInvalidCredentials
+	if session == nil || session.UserID != user.ID {
+		return commons.ErrSessionNotFound
+	}
+
+	if session.RevokedAt != nil {
+		return nil
+	}
+
+	return u.Database.RevokeSession(session.ID, time.Now())
+}
+
+func (u *UsersService) issueTokenPair(user domain.User, session domain.Session, refreshToken string) (*auth.TokenPair, error) {
+	accessToken, accessTokenExpiresAt, err := u.Tokens.IssueAccessToken(user, session.ID)
+	if err != nil {
+		return nil, err
 	}
 
-	return user, nil
+	return &auth.TokenPair{
+		AccessToken:           accessToken,
+		AccessTokenExpiresAt:  accessTokenExpiresAt,
+		RefreshToken:          refreshToken,
+		RefreshTokenExpiresAt: session.ExpiresAt,
+	}, nil
 }
 
-func mapRegisterUserRequestToUser(request requests.RegisterUserRequest) *domain.User {
+func mapRegisterUserRequestToUser(request requests.RegisterUserRequest, passwordHash string) *domain.User {
 	return &domain.User{
 		Email:    request.Email,
 		Name:     request.Name,
-		Password: request.Password,
+		Password: passwordHash,
 		DNI:      request.DNI,
 		Type:     commons.UserTypeClient,
 	}
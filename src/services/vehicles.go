@@ -0,0 +1,239 @@
+package services
+
+import (
+	"slices"
+	"time"
+
+	"backend/src/commons"
+	"backend/src/domain"
+	"backend/src/handlers/requests"
+)
+
+type VehiclesDatabase interface {
+	GetVehicles(includeRetired bool) ([]domain.Vehicle, error)
+	GetVehicleById(id uint) (*domain.Vehicle, error)
+	CreateVehicle(vehicle domain.Vehicle) (*domain.Vehicle, error)
+	UpdateVehicle(vehicle domain.Vehicle) (*domain.Vehicle, error)
+	ChangeVehicleStatus(vehicle domain.Vehicle, change domain.VehicleStatusChange) (*domain.Vehicle, error)
+	GetVehicleStatusHistory(vehicleID uint) ([]domain.VehicleStatusChange, error)
+	CountActiveBookingsByVehicleID(vehicleID uint, now time.Time) (int64, error)
+	GetMaintenanceWindowsByVehicleID(vehicleID uint) ([]domain.MaintenanceWindow, error)
+	GetMaintenanceWindowById(id uint) (*domain.MaintenanceWindow, error)
+	CreateMaintenanceWindow(window domain.MaintenanceWindow) (*domain.MaintenanceWindow, error)
+	DeleteMaintenanceWindow(id uint) error
+	GetOverlappingBookings(vehicleID uint, from time.Time, to time.Time) ([]domain.Booking, error)
+}
+
+// vehicleStatusTransitions lists the statuses a vehicle can move to from each
+// status. Retired vehicles are soft deleted and never come back.
+var vehicleStatusTransitions = map[string][]string{
+	commons.VehicleStatusAvailable: {
+		commons.VehicleStatusMaintenance,
+		commons.VehicleStatusOutOfService,
+		commons.VehicleStatusRetired,
+	},
+	commons.VehicleStatusMaintenance: {
+		commons.VehicleStatusAvailable,
+		commons.VehicleStatusOutOfService,
+		commons.VehicleStatusRetired,
+	},
+	commons.VehicleStatusOutOfService: {
+		commons.VehicleStatusAvailable,
+		commons.VehicleStatusMaintenance,
+		commons.VehicleStatusRetired,
+	},
+	commons.VehicleStatusRetired: {},
+}
+
+type VehiclesService struct {
+	Database VehiclesDatabase
+}
+
+func NewVehiclesService(database VehiclesDatabase) *VehiclesService {
+	return &VehiclesService{
+		Database: database,
+	}
+}
+
+func (v *VehiclesService) GetVehicles(includeRetired bool) ([]domain.Vehicle, error) {
+	return v.Database.GetVehicles(includeRetired)
+}
+
+func (v *VehiclesService) GetVehicleByID(vehicleID uint) (*domain.Vehicle, error) {
+	vehicle, err := v.Database.GetVehicleById(vehicleID)
+	if err != nil {
+		return nil, err
+	}
+
+	if vehicle == nil {
+		return nil, commons.ErrVehicleNotFound
+	}
+
+	return vehicle, nil
+}
+
+func (v *VehiclesService) CreateVehicle(request requests.CreateVehicleRequest) (*domain.Vehicle, error) {
+	vehicle := domain.Vehicle{
+		Status:           commons.VehicleStatusAvailable,
+		BrandModel:       request.BrandModel,
+		Brand:            request.Brand,
+		TransmissionType: request.TransmissionType,
+		Year:             request.Year,
+		Type:             request.Type,
+		Branch:           request.Branch,
+		HourlyFare:       request.HourlyFare,
+		RatePlan:         mapRatePlanRequestToRatePlan(request.RatePlan),
+	}
+
+	return v.Database.CreateVehicle(vehicle)
+}
+
+func (v *VehiclesService) UpdateVehicle(request requests.UpdateVehicleRequest) (*domain.Vehicle, error) {
+	vehicle, err := v.Database.GetVehicleById(request.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if vehicle == nil {
+		return nil, commons.ErrVehicleNotFound
+	}
+
+	vehicle.BrandModel = request.BrandModel
+	vehicle.Brand = request.Brand
+	vehicle.TransmissionType = request.TransmissionType
+	vehicle.Year = request.Year
+	vehicle.Type = request.Type
+	vehicle.Branch = request.Branch
+	vehicle.HourlyFare = request.HourlyFare
+	vehicle.RatePlan = mapRatePlanRequestToRatePlan(request.RatePlan)
+
+	return v.Database.UpdateVehicle(*vehicle)
+}
+
+func (v *VehiclesService) ChangeVehicleStatus(user domain.User, request requests.ChangeVehicleStatusRequest) (*domain.Vehicle, error) {
+	vehicle, err := v.Database.GetVehicleById(request.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if vehicle == nil {
+		return nil, commons.ErrVehicleNotFound
+	}
+
+	if !slices.Contains(vehicleStatusTransitions[vehicle.Status], request.Status) {
+		return nil, commons.ErrInvalidVehicleStatus
+	}
+
+	if request.Status == commons.VehicleStatusRetired {
+		activeBookings, err := v.Database.CountActiveBookingsByVehicleID(vehicle.ID, time.Now())
+		if err != nil {
+			return nil, err
+		}
+
+		if activeBookings > 0 {
+			return nil, commons.ErrVehicleHasActiveBookings
+		}
+	}
+
+	change := domain.VehicleStatusChange{
+		VehicleID:  vehicle.ID,
+		ActorID:    user.ID,
+		FromStatus: vehicle.Status,
+		ToStatus:   request.Status,
+		Reason:     request.Reason,
+	}
+
+	return v.Database.ChangeVehicleStatus(*vehicle, change)
+}
+
+// RetireVehicle moves the vehicle to the retired status, which soft deletes it
+// so it can no longer be booked while its past bookings keep pointing to it.
+func (v *VehiclesService) RetireVehicle(user domain.User, vehicleID uint) error {
+	_, err := v.ChangeVehicleStatus(user, requests.ChangeVehicleStatusRequest{
+		ID:     vehicleID,
+		Status: commons.VehicleStatusRetired,
+		Reason: "Vehicle retired",
+	})
+
+	return err
+}
+
+func (v *VehiclesService) GetVehicleStatusHistory(vehicleID uint) ([]domain.VehicleStatusChange, error) {
+	vehicle, err := v.Database.GetVehicleById(vehicleID)
+	if err != nil {
+		return nil, err
+	}
+
+	if vehicle == nil {
+		return nil, commons.ErrVehicleNotFound
+	}
+
+	return v.Database.GetVehicleStatusHistory(vehicle.ID)
+}
+
+func (v *VehiclesService) GetMaintenanceWindows(vehicleID uint) ([]domain.MaintenanceWindow, error) {
+	vehicle, err := v.Database.GetVehicleById(vehicleID)
+	if err != nil {
+		return nil, err
+	}
+
+	if vehicle == nil {
+		return nil, commons.ErrVehicleNotFound
+	}
+
+	return v.Database.GetMaintenanceWindowsByVehicleID(vehicle.ID)
+}
+
+// ScheduleMaintenance blocks the vehicle for the requested window and returns
+// the bookings that already hold the vehicle during it, so staff can move or
+// cancel them.
+func (v *VehiclesService) ScheduleMaintenance(user domain.User, request requests.CreateMaintenanceWindowRequest) (*domain.MaintenanceWindow, []domain.Booking, error) {
+	vehicle, err := v.Database.GetVehicleById(request.VehicleID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if vehicle == nil {
+		return nil, nil, commons.ErrVehicleNotFound
+	}
+
+	window, err := v.Database.CreateMaintenanceWindow(domain.MaintenanceWindow{
+		VehicleID:   vehicle.ID,
+		StartDate:   request.StartDate,
+		EndDate:     request.EndDate,
+		Reason:      request.Reason,
+		CreatedByID: user.ID,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	conflicts, err := v.Database.GetOverlappingBookings(vehicle.ID, window.StartDate, window.EndDate)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return window, conflicts, nil
+}
+
+func (v *VehiclesService) DeleteMaintenanceWindow(vehicleID uint, windowID uint) error {
+	window, err := v.Database.GetMaintenanceWindowById(windowID)
+	if err != nil {
+		return err
+	}
+
+	if window == nil || window.VehicleID != vehicleID {
+		return commons.ErrMaintenanceWindowNotFound
+	}
+
+	return v.Database.DeleteMaintenanceWindow(window.ID)
+}
+
+func mapRatePlanRequestToRatePlan(request requests.RatePlan) domain.RatePlan {
+	return domain.RatePlan{
+		DailyCap:          request.DailyCap,
+		WeeklyRate:        request.WeeklyRate,
+		WeekendHourlyFare: request.WeekendHourlyFare,
+		MinimumHours:      request.MinimumHours,
+	}
+}
@@ -11,11 +11,14 @@ import (
 	"github.com/labstack/gommon/log"
 	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type Client interface {
 	services.UsersDatabase
 	services.BookingsDatabase
+	services.VehiclesDatabase
+	services.PromotionsDatabase
 }
 
 type client struct {
@@ -33,11 +36,28 @@ func NewClient(dsn string) Client {
 		&domain.User{},
 		&domain.Booking{},
 		&domain.BookingMessage{},
+		&domain.BookingStatusChange{},
+		&domain.BookingExtension{},
+		&domain.BookingLineItem{},
+		&domain.Payment{},
+		&domain.BookingCharge{},
+		&domain.DepositSettlement{},
+		&domain.Refund{},
+		&domain.WebhookEvent{},
 		&domain.Vehicle{},
+		&domain.Promotion{},
+		&domain.VehicleStatusChange{},
+		&domain.MaintenanceWindow{},
+		&domain.Session{},
+		&domain.RefreshToken{},
 	); err != nil {
 		log.Error(err)
 	}
 
+	if err := migrateData(db); err != nil {
+		log.Error(err)
+	}
+
 	return &client{
 		DB: db,
 	}
@@ -71,15 +91,306 @@ func (c client) GetVehicleById(id uint) (*domain.Vehicle, error) {
 	return &vehicle, nil
 }
 
-func (c client) CreateBooking(booking domain.Booking) (*domain.Booking, error) {
-	result := c.DB.Create(&booking)
+func (c client) GetVehicles(includeRetired bool) ([]domain.Vehicle, error) {
+	var vehicles []domain.Vehicle
+	query := c.DB.Order("id asc")
+	if includeRetired {
+		query = query.Unscoped()
+	}
+
+	result := query.Find(&vehicles)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return vehicles, nil
+}
+
+func (c client) CreateVehicle(vehicle domain.Vehicle) (*domain.Vehicle, error) {
+	result := c.DB.Create(&vehicle)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return &vehicle, nil
+}
+
+func (c client) UpdateVehicle(vehicle domain.Vehicle) (*domain.Vehicle, error) {
+	result := c.DB.Save(&vehicle)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return &vehicle, nil
+}
+
+// ChangeVehicleStatus updates the status of the vehicle and records the change
+// in its history. Retired vehicles are soft deleted in the same transaction.
+func (c client) ChangeVehicleStatus(vehicle domain.Vehicle, change domain.VehicleStatusChange) (*domain.Vehicle, error) {
+	err := c.DB.Transaction(func(tx *gorm.DB) error {
+		vehicle.Status = change.ToStatus
+		if err := tx.Model(&vehicle).Update("status", change.ToStatus).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Create(&change).Error; err != nil {
+			return err
+		}
+
+		if change.ToStatus == commons.VehicleStatusRetired {
+			return tx.Delete(&vehicle).Error
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &vehicle, nil
+}
+
+func (c client) GetVehicleStatusHistory(vehicleID uint) ([]domain.VehicleStatusChange, error) {
+	var changes []domain.VehicleStatusChange
+	result := c.DB.
+		Where("vehicle_id = ?", vehicleID).
+		Order("created_at asc").
+		Find(&changes)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return changes, nil
+}
+
+func (c client) CountActiveBookingsByVehicleID(vehicleID uint, now time.Time) (int64, error) {
+	var count int64
+	result := c.DB.Model(&domain.Booking{}).
+		Where("vehicle_id = ? AND status IN ? AND (end_date > ? OR status IN ?)",
+			vehicleID,
+			commons.BookingStatusesBlockingVehicle,
+			now,
+			commons.BookingStatusesRentedOut).
+		Count(&count)
+	if result.Error != nil {
+		return 0, result.Error
+	}
+
+	return count, nil
+}
+
+func (c client) GetMaintenanceWindowsByVehicleID(vehicleID uint) ([]domain.MaintenanceWindow, error) {
+	var windows []domain.MaintenanceWindow
+	result := c.DB.
+		Where("vehicle_id = ?", vehicleID).
+		Order("start_date asc").
+		Find(&windows)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return windows, nil
+}
+
+func (c client) GetMaintenanceWindowById(id uint) (*domain.MaintenanceWindow, error) {
+	var window domain.MaintenanceWindow
+	result := c.DB.First(&window, id)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+
+		return nil, result.Error
+	}
+
+	return &window, nil
+}
+
+func (c client) CreateMaintenanceWindow(window domain.MaintenanceWindow) (*domain.MaintenanceWindow, error) {
+	result := c.DB.Create(&window)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return &window, nil
+}
+
+func (c client) DeleteMaintenanceWindow(id uint) error {
+	return c.DB.Delete(&domain.MaintenanceWindow{}, id).Error
+}
+
+func (c client) GetOverlappingBookings(vehicleID uint, from time.Time, to time.Time) ([]domain.Booking, error) {
+	var bookings []domain.Booking
+	result := overlappingBookings(c.DB, from, to).
+		Preload("Vehicle", withRetired).
+		Where("vehicle_id = ?", vehicleID).
+		Order("start_date asc").
+		Find(&bookings)
 	if result.Error != nil {
 		return nil, result.Error
 	}
 
+	return bookings, nil
+}
+
+// CreateBooking stores the booking only if its vehicle is free for the booked
+// dates. The vehicle row is locked for the duration of the transaction, so
+// concurrent bookings of the same vehicle are checked one after the other.
+func (c client) CreateBooking(booking domain.Booking, turnaround services.Turnaround) (*domain.Booking, error) {
+	err := c.DB.Transaction(func(tx *gorm.DB) error {
+		if err := reserveVehicle(tx, booking, turnaround); err != nil {
+			return err
+		}
+
+		if booking.PromotionID != nil {
+			if err := redeemPromotion(tx, booking); err != nil {
+				return err
+			}
+		}
+
+		return tx.Omit("Promotion").Create(&booking).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
 	return &booking, nil
 }
 
+// RescheduleBooking moves booking to its new dates if its vehicle is free then,
+// storing the payments authorized for the new price along with it.
+func (c client) RescheduleBooking(booking domain.Booking, turnaround services.Turnaround, payments ...domain.Payment) (*domain.Booking, error) {
+	err := c.DB.Transaction(func(tx *gorm.DB) error {
+		if err := reserveVehicle(tx, booking, turnaround); err != nil {
+			return err
+		}
+
+		for _, payment := range payments {
+			if err := tx.Omit(clause.Associations).Save(&payment).Error; err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Omit("Payments").Save(&booking).Error; err != nil {
+			return err
+		}
+
+		return pruneLineItems(tx, booking)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return c.GetBookingById(booking.ID)
+}
+
+// SaveBookingExtension stores extension along with the changes it made to
+// booking. Approved extensions re-check that the vehicle is still free until
+// the new end date.
+func (c client) SaveBookingExtension(booking domain.Booking, extension domain.BookingExtension, turnaround services.Turnaround) (*domain.Booking, error) {
+	err := c.DB.Transaction(func(tx *gorm.DB) error {
+		if extension.Status == commons.ExtensionStatusApproved {
+			if err := reserveVehicle(tx, booking, turnaround); err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Omit(clause.Associations).Save(&extension).Error; err != nil {
+			return err
+		}
+
+		// Extensions were saved above; saving them again as an association
+		// would only insert and never update existing ones
+		if err := tx.Omit("Extensions").Save(&booking).Error; err != nil {
+			return err
+		}
+
+		return pruneLineItems(tx, booking)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return c.GetBookingById(booking.ID)
+}
+
+// reserveVehicle locks the vehicle of booking until the transaction ends and
+// checks nothing else keeps it busy during the booking dates, turnaround
+// included. The booking itself is ignored so it can be moved onto a slot that
+// overlaps its own.
+func reserveVehicle(tx *gorm.DB, booking domain.Booking, turnaround services.Turnaround) error {
+	var vehicle domain.Vehicle
+	result := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&vehicle, booking.VehicleID)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return commons.ErrVehicleNotFound
+		}
+
+		return result.Error
+	}
+
+	buffer := turnaround.For(vehicle.Type)
+	var conflicts int64
+	result = overlappingBookings(tx, booking.StartDate.Add(-buffer), booking.EndDate.Add(buffer)).
+		Where("vehicle_id = ? AND id <> ?", booking.VehicleID, booking.ID).
+		Count(&conflicts)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	var maintenanceConflicts int64
+	result = overlappingMaintenanceWindows(tx, booking.StartDate, booking.EndDate).
+		Where("vehicle_id = ?", booking.VehicleID).
+		Count(&maintenanceConflicts)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if vehicle.Status != commons.VehicleStatusAvailable || conflicts > 0 || maintenanceConflicts > 0 {
+		return commons.ErrVehicleNotAvailable
+	}
+
+	return nil
+}
+
+// redeemPromotion takes one use of the promotion of booking. The promotion stays
+// locked until the transaction ends, so concurrent bookings cannot go over its
+// limits. Every booking made with the promotion counts as a use, even if it is
+// later cancelled.
+func redeemPromotion(tx *gorm.DB, booking domain.Booking) error {
+	var promotion domain.Promotion
+	result := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&promotion, *booking.PromotionID)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return commons.ErrInvalidPromoCode
+		}
+
+		return result.Error
+	}
+
+	if promotion.MaxUses > 0 && promotion.UsesCount >= promotion.MaxUses {
+		return commons.ErrPromotionExhausted
+	}
+
+	if promotion.MaxUsesPerUser > 0 {
+		// A plain read would see the snapshot the transaction took before the
+		// promotion was locked, missing bookings committed since
+		var uses int64
+		result = tx.Clauses(clause.Locking{Strength: "UPDATE"}).Model(&domain.Booking{}).
+			Where("promotion_id = ? AND user_id = ?", promotion.ID, booking.UserID).
+			Count(&uses)
+		if result.Error != nil {
+			return result.Error
+		}
+
+		if uses >= int64(promotion.MaxUsesPerUser) {
+			return commons.ErrPromotionExhausted
+		}
+	}
+
+	return tx.Model(&promotion).Update("uses_count", gorm.Expr("uses_count + 1")).Error
+}
+
 func (c client) GetUserByEmail(email string) (*domain.User, error) {
 	var user domain.User
 	result := c.DB.Where("email = ?", email).First(&user)
@@ -103,31 +414,243 @@ func (c client) CreateUser(user domain.User) (*domain.User, error) {
 	return &user, nil
 }
 
-func (c client) GetAvailableVehicles(from time.Time, to time.Time) ([]domain.Vehicle, error) {
+func (c client) UpdateUser(user domain.User) (*domain.User, error) {
+	result := c.DB.Save(&user)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return &user, nil
+}
+
+func (c client) CreateSession(session domain.Session) (*domain.Session, error) {
+	result := c.DB.Create(&session)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return &session, nil
+}
+
+func (c client) GetSessionById(id uint) (*domain.Session, error) {
+	var session domain.Session
+	result := c.DB.First(&session, id)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+
+		return nil, result.Error
+	}
+
+	return &session, nil
+}
+
+func (c client) GetActiveSessionsByUserID(userID uint, now time.Time) ([]domain.Session, error) {
+	var sessions []domain.Session
+	result := c.DB.
+		Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userID, now).
+		Order("last_used_at desc").
+		Find(&sessions)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return sessions, nil
+}
+
+func (c client) GetRefreshTokenByHash(hash string) (*domain.RefreshToken, error) {
+	var token domain.RefreshToken
+	result := c.DB.
+		Preload("Session").
+		Where("token_hash = ?", hash).
+		First(&token)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+
+		return nil, result.Error
+	}
+
+	return &token, nil
+}
+
+// RotateRefreshToken marks current as used and stores next in its place. The
+// conditional update makes sure only one request can rotate a given token.
+func (c client) RotateRefreshToken(current domain.RefreshToken, next domain.RefreshToken) (*domain.RefreshToken, error) {
+	err := c.DB.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		result := tx.Model(&domain.RefreshToken{}).
+			Where("id = ? AND rotated_at IS NULL", current.ID).
+			Update("rotated_at", now)
+		if result.Error != nil {
+			return result.Error
+		}
+
+		if result.RowsAffected == 0 {
+			return commons.ErrRefreshTokenReused
+		}
+
+		if err := tx.Create(&next).Error; err != nil {
+			return err
+		}
+
+		return tx.Model(&domain.Session{}).
+			Where("id = ?", next.SessionID).
+			Updates(map[string]interface{}{
+				"last_used_at": now,
+				"expires_at":   next.ExpiresAt,
+			}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.DB.First(&next.Session, next.SessionID).Error; err != nil {
+		return nil, err
+	}
+
+	return &next, nil
+}
+
+func (c client) RevokeSession(id uint, revokedAt time.Time) error {
+	return c.DB.Model(&domain.Session{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Update("revoked_at", revokedAt).Error
+}
+
+func (c client) GetPromotions() ([]domain.Promotion, error) {
+	var promotions []domain.Promotion
+	result := c.DB.Order("valid_from desc, id desc").Find(&promotions)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return promotions, nil
+}
+
+func (c client) GetPromotionById(id uint) (*domain.Promotion, error) {
+	var promotion domain.Promotion
+	result := c.DB.First(&promotion, id)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+
+		return nil, result.Error
+	}
+
+	return &promotion, nil
+}
+
+// GetPromotionByCode also finds deleted promotions, since their codes are
+// still taken by the unique index.
+func (c client) GetPromotionByCode(code string) (*domain.Promotion, error) {
+	var promotion domain.Promotion
+	result := c.DB.Unscoped().Where("code = ?", code).First(&promotion)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+
+		return nil, result.Error
+	}
+
+	return &promotion, nil
+}
+
+func (c client) CreatePromotion(promotion domain.Promotion) (*domain.Promotion, error) {
+	result := c.DB.Create(&promotion)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return &promotion, nil
+}
+
+func (c client) UpdatePromotion(promotion domain.Promotion) (*domain.Promotion, error) {
+	result := c.DB.Save(&promotion)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return &promotion, nil
+}
+
+func (c client) DeletePromotion(id uint) error {
+	return c.DB.Delete(&domain.Promotion{}, id).Error
+}
+
+func (c client) GetAvailableVehicles(filter services.VehicleFilter) ([]domain.Vehicle, int64, error) {
 	var vehicles []domain.Vehicle
 
-	subQuery := c.DB.Model(&domain.Booking{}).
-		Select("vehicle_id").
-		Where("start_date < ? AND end_date > ? AND status IN (?, ?)",
-			to,
-			from,
-			commons.BookingStatusReserved,
-			commons.BookingStatusConfirmed)
-	result := c.DB.Model(&domain.Vehicle{}).
-		Where("id NOT IN (?)", subQuery).
+	vehiclesInMaintenance := overlappingMaintenanceWindows(c.DB, filter.From, filter.To).Select("vehicle_id")
+	query := c.DB.Model(&domain.Vehicle{}).
+		Where("status = ? AND id NOT IN (?)",
+			commons.VehicleStatusAvailable,
+			vehiclesInMaintenance).
+		Where(unbookedVehicles(c.DB, filter.From, filter.To, filter.Turnaround))
+
+	if filter.Brand != "" {
+		query = query.Where("brand = ?", filter.Brand)
+	}
+	if filter.Type != "" {
+		query = query.Where("type = ?", filter.Type)
+	}
+	if filter.TransmissionType != "" {
+		query = query.Where("transmission_type = ?", filter.TransmissionType)
+	}
+	if filter.MinYear != 0 {
+		query = query.Where("year >= ?", filter.MinYear)
+	}
+	if filter.MaxYear != 0 {
+		query = query.Where("year <= ?", filter.MaxYear)
+	}
+	if filter.MaxHourlyFare != 0 {
+		query = query.Where("hourly_fare <= ?", filter.MaxHourlyFare)
+	}
+	if filter.Branch != "" {
+		query = query.Where("branch = ?", filter.Branch)
+	}
+
+	// Count and Find must not share the statement being built above
+	query = query.Session(&gorm.Session{})
+
+	var total int64
+	if result := query.Count(&total); result.Error != nil {
+		return nil, 0, result.Error
+	}
+
+	result := query.
+		Order(vehicleSortOrder(filter.Sort)).
+		Offset(filter.Offset).
+		Limit(filter.Limit).
 		Find(&vehicles)
 	if result.Error != nil {
-		return nil, result.Error
+		return nil, 0, result.Error
 	}
 
-	return vehicles, nil
+	return vehicles, total, nil
 }
 
 func (c client) GetBookingById(id uint) (*domain.Booking, error) {
 	var booking domain.Booking
 	result := c.DB.
-		Preload("Vehicle").
+		Preload("Vehicle", withRetired).
 		Preload("Messages").
+		Preload("StatusChanges", func(db *gorm.DB) *gorm.DB {
+			return db.Order("created_at asc, id asc")
+		}).
+		Preload("Extensions", func(db *gorm.DB) *gorm.DB {
+			return db.Order("created_at asc, id asc")
+		}).
+		Preload("LineItems").
+		Preload("Payments").
+		Preload("Charges").
+		Preload("Settlement").
+		Preload("Refunds").
+		Preload("Promotion", withDeleted).
 		First(&booking, id)
 	if result.Error != nil {
 		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
@@ -140,41 +663,138 @@ func (c client) GetBookingById(id uint) (*domain.Booking, error) {
 	return &booking, nil
 }
 
-func (c client) GetBookingsByUserID(userID uint) ([]domain.Booking, error) {
+// GetBookings lists bookings with their vehicle and line items. Messages are
+// only loaded when fetching a single booking.
+func (c client) GetBookings(filter services.BookingFilter) ([]domain.Booking, int64, error) {
 	var bookings []domain.Booking
-	result := c.DB.
-		Preload("Vehicle").
+
+	query := c.DB.Model(&domain.Booking{})
+	if filter.UserID != 0 {
+		query = query.Where("user_id = ?", filter.UserID)
+	}
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.VehicleID != 0 {
+		query = query.Where("vehicle_id = ?", filter.VehicleID)
+	}
+	if !filter.From.IsZero() {
+		query = query.Where("end_date > ?", filter.From)
+	}
+	if !filter.To.IsZero() {
+		query = query.Where("start_date < ?", filter.To)
+	}
+	if filter.Overdue {
+		query = query.Where("(status = ? OR (status = ? AND end_date < ?))",
+			commons.BookingStatusOverdue,
+			commons.BookingStatusInProgress,
+			filter.Now)
+	}
+
+	// Count and Find must not share the statement being built above
+	query = query.Session(&gorm.Session{})
+
+	var total int64
+	if result := query.Count(&total); result.Error != nil {
+		return nil, 0, result.Error
+	}
+
+	result := query.
+		Preload("Vehicle", withRetired).
 		Preload("Messages").
-		Where("user_id = ?", userID).
-		Order("start_date asc").
+		Preload("LineItems").
+		Preload("Payments").
+		Preload("Refunds").
+		Preload("Promotion", withDeleted).
+		Order(bookingSortOrder(filter.Sort)).
+		Offset(filter.Offset).
+		Limit(filter.Limit).
 		Find(&bookings)
 	if result.Error != nil {
-		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
-			return nil, nil
-		}
+		return nil, 0, result.Error
+	}
 
+	return bookings, total, nil
+}
+
+// GetExpiredReservations returns the reservations created before
+// createdBefore or whose start date is not after now.
+func (c client) GetExpiredReservations(createdBefore time.Time, now time.Time) ([]domain.Booking, error) {
+	var bookings []domain.Booking
+	result := c.DB.
+		Where("status = ? AND (created_at < ? OR start_date <= ?)",
+			commons.BookingStatusReserved,
+			createdBefore,
+			now).
+		Find(&bookings)
+	if result.Error != nil {
 		return nil, result.Error
 	}
+
 	return bookings, nil
 }
 
-func (c client) GetAdminBookings() ([]domain.Booking, error) {
+func (c client) GetConfirmedBookingsStartedBefore(startBefore time.Time) ([]domain.Booking, error) {
 	var bookings []domain.Booking
 	result := c.DB.
-		Preload("Vehicle").
-		Preload("Messages").
-		Order("start_date asc").
+		Preload("Payments").
+		Preload("Refunds").
+		Where("status = ? AND start_date < ?", commons.BookingStatusConfirmed, startBefore).
 		Find(&bookings)
 	if result.Error != nil {
-		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
-			return nil, nil
-		}
+		return nil, result.Error
+	}
 
+	return bookings, nil
+}
+
+func (c client) GetInProgressBookingsEndedBefore(endBefore time.Time) ([]domain.Booking, error) {
+	var bookings []domain.Booking
+	result := c.DB.
+		Where("status = ? AND end_date < ?", commons.BookingStatusInProgress, endBefore).
+		Find(&bookings)
+	if result.Error != nil {
 		return nil, result.Error
 	}
+
 	return bookings, nil
 }
 
+// TransitionBooking saves the status of booking and the last change in its
+// history, but only if the booking is still in fromStatus. It reports whether
+// it was, so changes made since the booking was loaded are never overwritten.
+func (c client) TransitionBooking(booking domain.Booking, fromStatus string) (bool, error) {
+	transitioned := false
+	err := c.DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&domain.Booking{}).
+			Where("id = ? AND status = ?", booking.ID, fromStatus).
+			Updates(map[string]interface{}{
+				"status":       booking.Status,
+				"observations": booking.Observations,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+
+		if result.RowsAffected == 0 {
+			return nil
+		}
+
+		transitioned = true
+		if len(booking.StatusChanges) == 0 {
+			return nil
+		}
+
+		change := booking.StatusChanges[len(booking.StatusChanges)-1]
+		return tx.Create(&change).Error
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return transitioned, nil
+}
+
 func (c client) UpdateBooking(booking domain.Booking) (*domain.Booking, error) {
 	result := c.DB.Save(&booking)
 	if result.Error != nil {
@@ -183,3 +803,263 @@ func (c client) UpdateBooking(booking domain.Booking) (*domain.Booking, error) {
 
 	return &booking, nil
 }
+
+// SaveBookingPayments saves booking along with payments made for it,
+// replacing its line items with the ones it has now.
+func (c client) SaveBookingPayments(booking domain.Booking, payments ...domain.Payment) (*domain.Booking, error) {
+	err := c.DB.Transaction(func(tx *gorm.DB) error {
+		return saveBookingPayments(tx, booking, payments)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return c.GetBookingById(booking.ID)
+}
+
+// SaveBookingTransition saves booking and payments like SaveBookingPayments,
+// but only if the booking is still in fromStatus, the status it was loaded
+// with. Otherwise it fails with ErrBookingStatusChanged and nothing is saved.
+func (c client) SaveBookingTransition(booking domain.Booking, fromStatus string, payments ...domain.Payment) (*domain.Booking, error) {
+	err := c.DB.Transaction(func(tx *gorm.DB) error {
+		if err := lockBookingStatus(tx, booking.ID, fromStatus); err != nil {
+			return err
+		}
+
+		return saveBookingPayments(tx, booking, payments)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return c.GetBookingById(booking.ID)
+}
+
+func (c client) GetPendingRefunds() ([]domain.Refund, error) {
+	var refunds []domain.Refund
+	result := c.DB.Where("status = ?", commons.RefundStatusPending).Order("id asc").Find(&refunds)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return refunds, nil
+}
+
+func (c client) UpdateRefund(refund domain.Refund) (*domain.Refund, error) {
+	result := c.DB.Save(&refund)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return &refund, nil
+}
+
+func (c client) GetPaymentByReference(provider string, reference string) (*domain.Payment, error) {
+	var payment domain.Payment
+	result := c.DB.Where("provider = ? AND provider_reference = ?", provider, reference).First(&payment)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+
+		return nil, result.Error
+	}
+
+	return &payment, nil
+}
+
+func (c client) UpdatePayment(payment domain.Payment) (*domain.Payment, error) {
+	result := c.DB.Save(&payment)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return &payment, nil
+}
+
+func (c client) GetRefundByReference(provider string, reference string) (*domain.Refund, error) {
+	var refund domain.Refund
+	result := c.DB.Where("provider = ? AND provider_reference = ?", provider, reference).First(&refund)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+
+		return nil, result.Error
+	}
+
+	return &refund, nil
+}
+
+// ClaimWebhookEvent records event as processed and runs process with a
+// database bound to the same transaction, so the event is stored along with
+// what processing it changed. It reports whether the event was claimed. A
+// delivery of an event already recorded is not processed again, and one
+// racing the first delivery waits for it on the unique index of the events.
+// If process fails nothing is recorded, so the event can be delivered again.
+func (c client) ClaimWebhookEvent(event domain.WebhookEvent, process func(database services.BookingsDatabase) error) (bool, error) {
+	claimed := false
+	err := c.DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&event)
+		if result.Error != nil {
+			return result.Error
+		}
+
+		if result.RowsAffected == 0 {
+			return nil
+		}
+
+		claimed = true
+		return process(client{DB: tx})
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return claimed, nil
+}
+
+// pruneLineItems deletes the line items booking no longer has. Saving a
+// booking only adds and updates its line items, so repricing it needs this to
+// drop the old ones.
+func pruneLineItems(tx *gorm.DB, booking domain.Booking) error {
+	ids := make([]uint, 0, len(booking.LineItems))
+	for _, item := range booking.LineItems {
+		ids = append(ids, item.ID)
+	}
+
+	query := tx.Unscoped().Where("booking_id = ?", booking.ID)
+	if len(ids) > 0 {
+		query = query.Where("id NOT IN ?", ids)
+	}
+
+	return query.Delete(&domain.BookingLineItem{}).Error
+}
+
+// saveBookingPayments saves payments and then booking, replacing its line
+// items with the ones it has now.
+func saveBookingPayments(tx *gorm.DB, booking domain.Booking, payments []domain.Payment) error {
+	for _, payment := range payments {
+		if err := tx.Omit(clause.Associations).Save(&payment).Error; err != nil {
+			return err
+		}
+	}
+
+	// Payments were saved above; saving them again as an association
+	// would only insert and never update existing ones
+	if err := tx.Omit("Payments").Save(&booking).Error; err != nil {
+		return err
+	}
+
+	return pruneLineItems(tx, booking)
+}
+
+// lockBookingStatus locks the booking until the transaction ends, failing if
+// its status is no longer fromStatus.
+func lockBookingStatus(tx *gorm.DB, bookingID uint, fromStatus string) error {
+	var current domain.Booking
+	result := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id", "status").First(&current, bookingID)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return commons.ErrBookingNotFound
+		}
+
+		return result.Error
+	}
+
+	if current.Status != fromStatus {
+		return commons.ErrBookingStatusChanged
+	}
+
+	return nil
+}
+
+// overlappingBookings scopes bookings that keep their vehicle busy at some
+// point between from and to. Rentals not returned yet keep it busy past their
+// end date, but only until now, as they are expected back before any later
+// booking starts.
+func overlappingBookings(db *gorm.DB, from time.Time, to time.Time) *gorm.DB {
+	query := db.Model(&domain.Booking{}).
+		Where("start_date < ? AND status IN ?", to, commons.BookingStatusesBlockingVehicle)
+
+	if from.Before(time.Now()) {
+		return query.Where("(end_date > ? OR status IN ?)", from, commons.BookingStatusesRentedOut)
+	}
+
+	return query.Where("end_date > ?", from)
+}
+
+// unbookedVehicles scopes vehicles with no booking between from and to, nor
+// close enough to them to overlap its turnaround. Every vehicle type with its
+// own turnaround is checked against its own widened range.
+func unbookedVehicles(db *gorm.DB, from time.Time, to time.Time, turnaround services.Turnaround) *gorm.DB {
+	booked := func(buffer time.Duration) *gorm.DB {
+		return overlappingBookings(db, from.Add(-buffer), to.Add(buffer)).Select("vehicle_id")
+	}
+
+	if len(turnaround.ByVehicleType) == 0 {
+		return db.Where("id NOT IN (?)", booked(turnaround.Default))
+	}
+
+	types := make([]string, 0, len(turnaround.ByVehicleType))
+	condition := db
+	for vehicleType, buffer := range turnaround.ByVehicleType {
+		types = append(types, vehicleType)
+		condition = condition.Or("type = ? AND id NOT IN (?)", vehicleType, booked(buffer))
+	}
+
+	return condition.Or("type NOT IN ? AND id NOT IN (?)", types, booked(turnaround.Default))
+}
+
+// withRetired lets bookings keep loading vehicles that were retired after
+// being booked.
+func withRetired(db *gorm.DB) *gorm.DB {
+	return db.Unscoped()
+}
+
+// withDeleted keeps deleted promotions, which bookings made with them still
+// refer to.
+func withDeleted(db *gorm.DB) *gorm.DB {
+	return db.Unscoped()
+}
+
+// overlappingMaintenanceWindows scopes maintenance windows that take their
+// vehicle out of service at some point between from and to.
+func overlappingMaintenanceWindows(db *gorm.DB, from time.Time, to time.Time) *gorm.DB {
+	return db.Model(&domain.MaintenanceWindow{}).
+		Where("start_date < ? AND end_date > ?", to, from)
+}
+
+// vehicleSortOrder translates the public sort keys into ORDER BY clauses. The
+// id is always the last key so pages are stable.
+func vehicleSortOrder(sort string) string {
+	switch sort {
+	case "hourly_fare":
+		return "hourly_fare asc, id asc"
+	case "-hourly_fare":
+		return "hourly_fare desc, id asc"
+	case "year":
+		return "year asc, id asc"
+	case "-year":
+		return "year desc, id asc"
+	case "brand":
+		return "brand asc, brand_model asc, id asc"
+	case "-brand":
+		return "brand desc, brand_model desc, id asc"
+	default:
+		return "id asc"
+	}
+}
+
+func bookingSortOrder(sort string) string {
+	switch sort {
+	case "-start_date":
+		return "start_date desc, id desc"
+	case "created_at":
+		return "created_at asc, id asc"
+	case "-created_at":
+		return "created_at desc, id desc"
+	default:
+		return "start_date asc, id asc"
+	}
+}
@@ -0,0 +1,175 @@
+package sql
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"backend/src/commons"
+	"backend/src/domain"
+	"backend/src/services"
+)
+
+// testClient connects to the MySQL database in TEST_DATABASE_DSN, skipping the
+// test when it is not set. The database is migrated but never cleaned up, so it
+// should not be one in use.
+func testClient(t *testing.T) *client {
+	t.Helper()
+
+	dsn := os.Getenv("TEST_DATABASE_DSN")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_DSN is not set")
+	}
+
+	return NewClient(dsn).(*client)
+}
+
+// createTestUser stores a client with an email and DNI of its own.
+func createTestUser(t *testing.T, c *client) domain.User {
+	t.Helper()
+
+	suffix := time.Now().UnixNano()
+	user := domain.User{
+		Email:    fmt.Sprintf("test-%d@example.com", suffix),
+		Name:     "Test",
+		Password: "-",
+		DNI:      fmt.Sprintf("%d", suffix),
+		Type:     commons.UserTypeClient,
+	}
+	if err := c.DB.Create(&user).Error; err != nil {
+		t.Fatalf("creating user: %v", err)
+	}
+
+	return user
+}
+
+// createTestVehicle stores an available vehicle in a branch of its own, so
+// listings can be narrowed down to it.
+func createTestVehicle(t *testing.T, c *client) domain.Vehicle {
+	t.Helper()
+
+	vehicle := domain.Vehicle{
+		Status:           commons.VehicleStatusAvailable,
+		BrandModel:       "Corolla",
+		Brand:            "Toyota",
+		TransmissionType: commons.VehicleTransmissionManual,
+		Year:             2024,
+		Type:             commons.VehicleTypeSedan,
+		Branch:           fmt.Sprintf("test-%d", time.Now().UnixNano()),
+		HourlyFare:       10,
+	}
+	if err := c.DB.Create(&vehicle).Error; err != nil {
+		t.Fatalf("creating vehicle: %v", err)
+	}
+
+	return vehicle
+}
+
+// testBooking is a reservation of vehicle by user between start and end.
+func testBooking(user domain.User, vehicle domain.Vehicle, start time.Time, end time.Time) domain.Booking {
+	return domain.Booking{
+		Status:          commons.BookingStatusReserved,
+		UserID:          user.ID,
+		VehicleID:       vehicle.ID,
+		StartDate:       start,
+		EndDate:         end,
+		PickUpLocation:  "Centro",
+		DropOffLocation: "Centro",
+		HourlyFare:      vehicle.HourlyFare,
+	}
+}
+
+func TestCreateBookingConcurrentOverlaps(t *testing.T) {
+	c := testClient(t)
+	user := createTestUser(t, c)
+	vehicle := createTestVehicle(t, c)
+
+	const attempts = 10
+	start := time.Now().Add(24 * time.Hour).Truncate(time.Hour)
+	turnaround := services.Turnaround{Default: time.Hour}
+
+	var wg sync.WaitGroup
+	errs := make([]error, attempts)
+	for i := 0; i < attempts; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			// Every window overlaps every other one by at least a day.
+			_, errs[i] = c.CreateBooking(testBooking(user, vehicle,
+				start.Add(time.Duration(i)*time.Hour),
+				start.Add(48*time.Hour+time.Duration(i)*time.Hour)), turnaround)
+		}(i)
+	}
+	wg.Wait()
+
+	succeeded := 0
+	for i, err := range errs {
+		if err == nil {
+			succeeded++
+			continue
+		}
+
+		if !errors.Is(err, commons.ErrVehicleNotAvailable) {
+			t.Errorf("attempt %d: got %v, want %v", i, err, commons.ErrVehicleNotAvailable)
+		}
+	}
+
+	if succeeded != 1 {
+		t.Errorf("%d bookings succeeded, want 1", succeeded)
+	}
+
+	var stored int64
+	if err := c.DB.Model(&domain.Booking{}).Where("vehicle_id = ?", vehicle.ID).Count(&stored).Error; err != nil {
+		t.Fatalf("counting bookings: %v", err)
+	}
+
+	if stored != 1 {
+		t.Errorf("%d bookings stored for the vehicle, want 1", stored)
+	}
+}
+
+func TestUnreturnedRentalBlocksVehicleOnlyUntilNow(t *testing.T) {
+	c := testClient(t)
+	user := createTestUser(t, c)
+	vehicle := createTestVehicle(t, c)
+	turnaround := services.Turnaround{Default: time.Hour}
+
+	now := time.Now().Truncate(time.Hour)
+	overdue := testBooking(user, vehicle, now.Add(-48*time.Hour), now.Add(-2*time.Hour))
+	overdue.Status = commons.BookingStatusOverdue
+	if err := c.DB.Create(&overdue).Error; err != nil {
+		t.Fatalf("creating overdue booking: %v", err)
+	}
+
+	// Its turnaround starts before now, when the vehicle is still out
+	soon := time.Now().Add(30 * time.Minute)
+	_, err := c.CreateBooking(testBooking(user, vehicle, soon, soon.Add(24*time.Hour)), turnaround)
+	if !errors.Is(err, commons.ErrVehicleNotAvailable) {
+		t.Errorf("booking right away: got %v, want %v", err, commons.ErrVehicleNotAvailable)
+	}
+
+	from := now.AddDate(0, 1, 0)
+	to := from.Add(72 * time.Hour)
+	vehicles, _, err := c.GetAvailableVehicles(services.VehicleFilter{
+		From:       from,
+		To:         to,
+		Branch:     vehicle.Branch,
+		Turnaround: turnaround,
+		Limit:      10,
+	})
+	if err != nil {
+		t.Fatalf("listing available vehicles: %v", err)
+	}
+
+	if len(vehicles) != 1 || vehicles[0].ID != vehicle.ID {
+		t.Errorf("vehicle not listed as available next month, got %d vehicles", len(vehicles))
+	}
+
+	if _, err := c.CreateBooking(testBooking(user, vehicle, from, to), turnaround); err != nil {
+		t.Errorf("booking next month: %v", err)
+	}
+}
@@ -0,0 +1,87 @@
+package sql
+
+import (
+	"time"
+
+	"backend/src/commons"
+	"backend/src/domain"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// dataMigration records a data migration already applied to the database.
+type dataMigration struct {
+	Name      string    `gorm:"primaryKey;size:191"`
+	AppliedAt time.Time `gorm:"not null"`
+}
+
+// dataMigrations fix existing rows after changes of the model, in order. Each
+// one runs once, in the same transaction that records it as applied.
+var dataMigrations = []struct {
+	name string
+	run  func(tx *gorm.DB, now time.Time) error
+}{
+	{"start-confirmed-rentals", startConfirmedRentals},
+}
+
+// migrateData applies the data migrations not applied yet. Several instances
+// starting at once apply each one only once, as the first to record it holds
+// the others back until it is done.
+func migrateData(db *gorm.DB) error {
+	if err := db.AutoMigrate(&dataMigration{}); err != nil {
+		return err
+	}
+
+	for _, migration := range dataMigrations {
+		err := db.Transaction(func(tx *gorm.DB) error {
+			now := time.Now()
+			result := tx.Clauses(clause.OnConflict{DoNothing: true}).
+				Create(&dataMigration{Name: migration.name, AppliedAt: now})
+			if result.Error != nil {
+				return result.Error
+			}
+
+			if result.RowsAffected == 0 {
+				return nil
+			}
+
+			return migration.run(tx, now)
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// startConfirmedRentals moves rentals that had already started when check-out
+// was introduced to in progress. Confirmed used to mean the customer had the
+// vehicle, so left confirmed they could not be finished and would be flagged
+// as no shows.
+func startConfirmedRentals(tx *gorm.DB, now time.Time) error {
+	var bookings []domain.Booking
+	result := tx.Where("status = ? AND start_date <= ?", commons.BookingStatusConfirmed, now).Find(&bookings)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	for _, booking := range bookings {
+		if err := tx.Model(&booking).Update("status", commons.BookingStatusInProgress).Error; err != nil {
+			return err
+		}
+
+		change := domain.BookingStatusChange{
+			BookingID:  booking.ID,
+			FromStatus: commons.BookingStatusConfirmed,
+			ToStatus:   commons.BookingStatusInProgress,
+			Reason:     "Rental already started when check-out was introduced",
+		}
+		if err := tx.Create(&change).Error; err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -2,6 +2,10 @@ package src
 
 import (
 	"net/http"
+	"slices"
+
+	"backend/src/auth"
+	"backend/src/commons"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/labstack/echo/v4"
@@ -13,6 +17,13 @@ type CustomValidator struct {
 
 func NewCustomValidator() *CustomValidator {
 	v := validator.New()
+	_ = v.RegisterValidation("booking_status", oneOf(commons.BookingStatuses))
+	_ = v.RegisterValidation("vehicle_status", oneOf(commons.VehicleStatuses))
+	_ = v.RegisterValidation("vehicle_transmission", oneOf(commons.VehicleTransmissionTypes))
+	_ = v.RegisterValidation("vehicle_type", oneOf(commons.VehicleTypes))
+	_ = v.RegisterValidation("discount_type", oneOf(commons.DiscountTypes))
+	_ = v.RegisterValidation("charge_kind", oneOf(commons.ChargeKinds))
+	_ = v.RegisterValidation("password_length", passwordLength)
 	return &CustomValidator{
 		validator: v,
 	}
@@ -24,3 +35,16 @@ func (cv *CustomValidator) Validate(i interface{}) error {
 	}
 	return nil
 }
+
+// oneOf validates that a string field holds one of the given values.
+func oneOf(values []string) validator.Func {
+	return func(fl validator.FieldLevel) bool {
+		return slices.Contains(values, fl.Field().String())
+	}
+}
+
+// passwordLength validates that a password fits in bcrypt, which only takes up
+// to 72 bytes. max=72 would count characters instead.
+func passwordLength(fl validator.FieldLevel) bool {
+	return len(fl.Field().String()) <= auth.MaxPasswordBytes
+}